@@ -0,0 +1,55 @@
+package yamlconv
+
+import "time"
+
+// Theme is the ANSI escape sequences to colour each kind of the yaml
+// struct by Printer and Encoder, e.g. "\x1b[34m" for blue.
+// An empty sequence leaves the kind uncoloured.
+type Theme struct {
+	Key    string // map keys
+	Index  string // list indexes
+	String string // strings
+	Number string // integers and floats
+	Bool   string // true and false
+	Null   string // null
+}
+
+// DefaultTheme is the theme for the terminals of dark background.
+var DefaultTheme = Theme{
+	Key:    "\x1b[1;34m",
+	Index:  "\x1b[36m",
+	String: "\x1b[32m",
+	Number: "\x1b[33m",
+	Bool:   "\x1b[35m",
+	Null:   "\x1b[90m",
+}
+
+// colorReset is the ANSI escape sequence to reset the colour.
+const colorReset = "\x1b[0m"
+
+// Paint returns s coloured by the escape sequence color, or s as it is
+// if color is empty.
+func (t *Theme) Paint(color, s string) string {
+	if t == nil || len(color) == 0 {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// scalar returns the escape sequence of the scalar data.
+func (t *Theme) scalar(data interface{}) string {
+	if t == nil {
+		return ""
+	}
+	switch data.(type) {
+	case nil:
+		return t.Null
+	case bool:
+		return t.Bool
+	case string, []byte, time.Time:
+		return t.String
+	case int, int64, uint64, float64, float32:
+		return t.Number
+	}
+	return ""
+}
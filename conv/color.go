@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/HaesungSeo/yamlconv"
+)
+
+// colorTheme returns the theme of the colour mode, one of auto, always
+// and never, or nil for no colour.
+// auto colours the output, if the standard out is a terminal and
+// NO_COLOR is not set.
+func colorTheme(mode string) *yamlconv.Theme {
+	switch mode {
+	case "always":
+		return &yamlconv.DefaultTheme
+	case "never":
+		return nil
+	case "auto":
+		if _, ok := os.LookupEnv("NO_COLOR"); ok {
+			return nil
+		}
+		fi, err := os.Stdout.Stat()
+		if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+			return nil
+		}
+		return &yamlconv.DefaultTheme
+	}
+	panic(fmt.Sprintf("ERROR: unknown color mode %s\n", mode))
+}
+
+// colorYaml returns the yaml text printed by yaml.Marshal coloured by
+// the theme, line by line.
+func colorYaml(text []byte, theme *yamlconv.Theme) []byte {
+	var b strings.Builder
+	block := -1 // the column of the key of a block scalar, if any
+	for _, line := range strings.SplitAfter(string(text), "\n") {
+		body := strings.TrimSuffix(line, "\n")
+		col := len(body) - len(strings.TrimLeft(body, " "))
+		if block >= 0 && (col > block || len(body) == 0) {
+			// the content of the block scalar
+			b.WriteString(body[:col] + theme.Paint(theme.String, body[col:]) + line[len(body):])
+			continue
+		}
+		block = -1
+
+		b.WriteString(body[:col])
+		rest := body[col:]
+		for rest == "-" || strings.HasPrefix(rest, "- ") {
+			n := len(rest) - len(strings.TrimLeft(rest[1:], " "))
+			b.WriteString(theme.Paint(theme.Index, "-") + rest[1:n])
+			rest = rest[n:]
+			col += n
+		}
+		if n := keyLength(rest); n > 0 {
+			b.WriteString(theme.Paint(theme.Key, rest[:n]) + ":")
+			rest = rest[n+1:]
+			if v := strings.TrimSpace(rest); strings.HasPrefix(v, "|") || strings.HasPrefix(v, ">") {
+				block = col
+			}
+		}
+		b.WriteString(colorScalar(rest, theme) + line[len(body):])
+	}
+	return []byte(b.String())
+}
+
+// keyLength returns the length of the map key at the start of line
+// followed by ':', or 0 if there is none.
+func keyLength(line string) int {
+	n := 0
+	switch {
+	case strings.HasPrefix(line, "\""):
+		for n = 1; n < len(line) && line[n] != '"'; n++ {
+			if line[n] == '\\' {
+				n++
+			}
+		}
+		n++
+	case strings.HasPrefix(line, "'"):
+		for n = 1; n < len(line); n++ {
+			if line[n] == '\'' {
+				if n+1 < len(line) && line[n+1] == '\'' {
+					n++
+					continue
+				}
+				break
+			}
+		}
+		n++
+	default:
+		n = strings.Index(line+" ", ": ")
+		if n <= 0 || strings.HasPrefix(line, "{") || strings.HasPrefix(line, "[") {
+			return 0
+		}
+		return n
+	}
+	if n >= len(line) || line[n] != ':' || (n+1 < len(line) && line[n+1] != ' ') {
+		return 0
+	}
+	return n
+}
+
+// colorScalar returns the scalar text coloured by its type, where text
+// may start with spaces.
+func colorScalar(text string, theme *yamlconv.Theme) string {
+	v := strings.TrimLeft(text, " ")
+	space := text[:len(text)-len(v)]
+	switch {
+	case len(v) == 0, v == "{}", v == "[]", strings.HasPrefix(v, "|"), strings.HasPrefix(v, ">"):
+		return text
+	case v == "null", v == "~":
+		return space + theme.Paint(theme.Null, v)
+	case v == "true", v == "false":
+		return space + theme.Paint(theme.Bool, v)
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil || v == ".inf" || v == "-.inf" || v == ".nan" {
+		return space + theme.Paint(theme.Number, v)
+	}
+	return space + theme.Paint(theme.String, v)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/HaesungSeo/yamlconv"
+	"gopkg.in/yaml.v2"
+)
+
+type ListKey map[string]string
+
+func (m *ListKey) String() string {
+	return fmt.Sprint(*m)
+}
+
+func (m *ListKey) Set(v string) error {
+	list, key, found := strings.Cut(v, "=")
+	if !found {
+		return fmt.Errorf("expect list=key, but %s", v)
+	}
+	if *m == nil {
+		*m = make(ListKey)
+	}
+	(*m)[list] = key
+	return nil
+}
+
+// diffMain prints the changes between two yaml files,
+// e.g. conv diff -f old.yaml -f new.yaml -o patch
+func diffMain(args []string) {
+	var yamlpaths FileList
+	var listKeys ListKey
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.Var(&yamlpaths, "f", "yaml file, exactly two times, the old one first")
+	ofmt := fs.String("o", "text", "output format, one of text, json, patch")
+	fs.Var(&listKeys, "key", "list item key of the lists under a map key compared by key, multiple times, e.g. -key sriov=network")
+	fs.Parse(args)
+
+	if len(yamlpaths) != 2 {
+		panic(fmt.Sprintf("ERROR: expect two -f files, but %d\n", len(yamlpaths)))
+	}
+	opts := yamlconv.DiffOptions{ListKeys: listKeys}
+	changes := opts.Diff(readYaml(yamlpaths[0]), readYaml(yamlpaths[1]))
+
+	var data interface{}
+	switch *ofmt {
+	case "text":
+		for _, c := range changes {
+			switch c.Type {
+			case yamlconv.ChangeAdded:
+				fmt.Printf("+ %s: %s\n", c.Path, flow(c.New))
+			case yamlconv.ChangeRemoved:
+				fmt.Printf("- %s: %s\n", c.Path, flow(c.Old))
+			case yamlconv.ChangeModified:
+				fmt.Printf("~ %s: %s -> %s\n", c.Path, flow(c.Old), flow(c.New))
+			}
+		}
+		return
+	case "json":
+		list := make([]interface{}, 0, len(changes))
+		for _, c := range changes {
+			item := yaml.MapSlice{
+				{Key: "type", Value: c.Type.String()},
+				{Key: "path", Value: c.Path.String()},
+			}
+			if c.Type != yamlconv.ChangeAdded {
+				item = append(item, yaml.MapItem{Key: "old", Value: c.Old})
+			}
+			if c.Type != yamlconv.ChangeRemoved {
+				item = append(item, yaml.MapItem{Key: "new", Value: c.New})
+			}
+			list = append(list, item)
+		}
+		data = list
+	case "patch":
+		data = yamlconv.JSONPatch(changes)
+	default:
+		panic(fmt.Sprintf("ERROR: unknown output format %s\n", *ofmt))
+	}
+	buf, err := yamlconv.MarshalJson(data, []string{})
+	if err != nil {
+		panic(fmt.Sprintf("ERROR: %s\n", err.Error()))
+	}
+	os.Stdout.Write(buf)
+}
+
+// flow returns the yaml struct in a single line of JSON.
+func flow(data interface{}) string {
+	buf, err := yamlconv.MarshalJson(data, []string{})
+	if err != nil {
+		panic(fmt.Sprintf("ERROR: %s\n", err.Error()))
+	}
+	return strings.TrimSpace(string(buf))
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/HaesungSeo/yamlconv"
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Edit is an edit of -set or -delete flags.
+type Edit struct {
+	Path   yamlconv.Path
+	Value  interface{}
+	Delete bool
+}
+
+// Edits is the edits of -set and -delete flags in the order of the flags.
+type Edits []Edit
+
+// SetEdit is the -set flag of path=value, where value is a yaml.
+type SetEdit struct{ *Edits }
+
+func (m SetEdit) String() string {
+	return ""
+}
+
+func (m SetEdit) Set(v string) error {
+	i := assignIndex(v)
+	if i < 0 {
+		return fmt.Errorf("expect path=value, but %s", v)
+	}
+	keys, err := yamlconv.ParsePath(v[:i])
+	if err != nil {
+		return err
+	}
+	value, err := decodeValue(v[i+1:])
+	if err != nil {
+		return fmt.Errorf("invalid value %s: %s", v[i+1:], err.Error())
+	}
+	*m.Edits = append(*m.Edits, Edit{Path: keys, Value: value})
+	return nil
+}
+
+// decodeValue returns the yaml struct of the yaml text src, where the
+// maps keep their key order as yaml.MapSlice at any depth, e.g. in a list.
+func decodeValue(src string) (interface{}, error) {
+	var node yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(src), &node); err != nil {
+		return nil, err
+	}
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+
+	// yaml.v2 decodes the maps under a yaml.MapSlice as yaml.MapSlice
+	buf, err := yamlv3.Marshal(&yamlv3.Node{Kind: yamlv3.MappingNode,
+		Content: []*yamlv3.Node{{Kind: yamlv3.ScalarNode, Value: "value"}, node.Content[0]}})
+	if err != nil {
+		return nil, err
+	}
+	var items yaml.MapSlice
+	if err := yaml.Unmarshal(buf, &items); err != nil {
+		return nil, err
+	}
+	return items[0].Value, nil
+}
+
+// DeleteEdit is the -delete flag of path.
+type DeleteEdit struct{ *Edits }
+
+func (m DeleteEdit) String() string {
+	return ""
+}
+
+func (m DeleteEdit) Set(v string) error {
+	keys, err := yamlconv.ParsePath(v)
+	if err != nil {
+		return err
+	}
+	*m.Edits = append(*m.Edits, Edit{Path: keys, Delete: true})
+	return nil
+}
+
+// apply returns the yaml struct edited in order.
+func (m Edits) apply(data interface{}) interface{} {
+	var err error
+	for _, e := range m {
+		if e.Delete {
+			data, err = yamlconv.Subtract(data, e.Path)
+		} else {
+			data, err = yamlconv.Upsert(data, e.Path, e.Value)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("ERROR: %s: %s\n", e.Path, err.Error()))
+		}
+	}
+	return data
+}
+
+// editFile edits the yaml file in order, keeping its comments and format,
+// and replaces the file atomically, keeping the original file of the
+// backup suffix, if any.
+func editFile(yamlpath string, edits Edits, backup string) {
+	filename, err := filepath.Abs(yamlpath)
+	if err != nil {
+		panic(err.Error())
+	}
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		panic(err.Error())
+	}
+	fi, err := os.Stat(filename)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	doc, err := yamlconv.ParseDocument(src)
+	if err != nil {
+		panic(fmt.Sprintf("ERROR: %s\n", err.Error()))
+	}
+	for _, e := range edits {
+		if e.Delete {
+			err = doc.Subtract(e.Path)
+		} else {
+			err = doc.Upsert(e.Path, e.Value)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("ERROR: %s: %s\n", e.Path, err.Error()))
+		}
+	}
+
+	if len(backup) > 0 {
+		if err := os.WriteFile(filename+backup, src, fi.Mode().Perm()); err != nil {
+			panic(err.Error())
+		}
+	}
+
+	// write a temporary file, and rename it to the file
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		panic(err.Error())
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(doc.Bytes()); err != nil {
+		tmp.Close()
+		panic(err.Error())
+	}
+	if err := tmp.Chmod(fi.Mode().Perm()); err != nil {
+		tmp.Close()
+		panic(err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		panic(err.Error())
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		panic(err.Error())
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/HaesungSeo/yamlconv"
+	"gopkg.in/yaml.v2"
+)
+
+// printPaths prints the leaves of the yaml struct in gron style,
+// one assignment per line, e.g. sriov[0].ip = "10.10.0.101";
+// where prefix is the path of the yaml struct.
+func printPaths(w io.Writer, prefix yamlconv.Path, data interface{}) {
+	for _, l := range yamlconv.Flatten(data) {
+		path := append(append(yamlconv.Path{}, prefix...), l.Path...)
+		for i, key := range path {
+			// quote the map keys of '=', which separates the value
+			if !strings.HasPrefix(key, "[") && strings.Contains(key, "=") {
+				path[i] = "[" + strconv.Quote(key) + "]"
+			}
+		}
+		name := path.String()
+		if len(name) == 0 {
+			name = "."
+		}
+		buf, err := yamlconv.MarshalJson(l.Value, []string{})
+		if err != nil {
+			panic(fmt.Sprintf("ERROR: %s\n", err.Error()))
+		}
+		fmt.Fprintf(w, "%s = %s;\n", name, buf)
+	}
+}
+
+// readPaths returns the yaml struct of the gron style file printed by
+// printPaths, ignoring the empty lines and comments.
+func readPaths(yamlpath string) interface{} {
+	f, err := os.Open(yamlpath)
+	if err != nil {
+		panic(err.Error())
+	}
+	defer f.Close()
+
+	var leaves []yamlconv.Leaf
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		l, err := parsePathLine(line)
+		if err != nil {
+			panic(fmt.Sprintf("ERROR: %s:%d: %s\n", yamlpath, n, err.Error()))
+		}
+		leaves = append(leaves, l)
+	}
+	if err := scanner.Err(); err != nil {
+		panic(err.Error())
+	}
+
+	data, err := yamlconv.Unflatten(leaves)
+	if err != nil {
+		panic(fmt.Sprintf("ERROR: %s\n", err.Error()))
+	}
+	return data
+}
+
+// jsonNumber is the number of JSON.
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// parsePathLine parses a line of the form path = value;
+// where value is a JSON value.
+func parsePathLine(line string) (yamlconv.Leaf, error) {
+	i := assignIndex(line)
+	if i < 0 {
+		return yamlconv.Leaf{}, fmt.Errorf("expect path = value, but %s", line)
+	}
+	name := strings.TrimSpace(line[:i])
+	var path yamlconv.Path
+	if name != "." {
+		var err error
+		path, err = yamlconv.ParsePath(name)
+		if err != nil {
+			return yamlconv.Leaf{}, err
+		}
+	}
+
+	var value interface{}
+	text := strings.TrimSuffix(strings.TrimSpace(line[i+1:]), ";")
+	if err := yaml.Unmarshal([]byte(text), &value); err != nil {
+		return yamlconv.Leaf{}, fmt.Errorf("invalid value %s: %s", text, err.Error())
+	}
+	// a number is read by the grammar of JSON printed by printPaths, e.g.
+	// 1e-07, not only by the one of yaml
+	if _, ok := value.(string); ok && jsonNumber.MatchString(text) {
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			value = f
+		}
+	}
+	return yamlconv.Leaf{Path: path, Value: value}, nil
+}
+
+// assignIndex returns the index of the first '=' outside of the brackets
+// of line, or -1 if there is none.
+func assignIndex(line string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && depth > 0:
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '=' && depth == 0:
+			return i
+		}
+	}
+	return -1
+}
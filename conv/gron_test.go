@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HaesungSeo/yamlconv"
+)
+
+func TestPathsRoundTrip(t *testing.T) {
+	tests := []string{
+		`{sriov: [{network: resource01, ip: 10.10.0.101}], mtu: 1500}`,
+		`{small: 1e-07, big: 1.0e+21, neg: -2.5e-3, half: 0.5, int: -3, zero: 0}`,
+		`{s: "1e-07", t: "12", u: "true", v: "null", w: ""}`,
+		`{a: true, b: null, c: {}, d: [], e: [[1], {f: [x]}]}`,
+		`{"a=b": 1, "x.y": {"[0]": 2}, "*": 3}`,
+	}
+	for _, src := range tests {
+		data := parseYaml(t, src)
+		var b bytes.Buffer
+		printPaths(&b, yamlconv.Path{}, data)
+
+		path := filepath.Join(t.TempDir(), "paths")
+		if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got := readPaths(path)
+		if changes := yamlconv.Diff(data, got); len(changes) != 0 {
+			t.Errorf("%s: -o paths\n%s-ungron\n%s", src, b.String(), yamlText(t, got))
+		}
+	}
+}
+
+func TestParsePathLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want interface{}
+	}{
+		{`a = 1e-07;`, 1e-07},
+		{`a = 1E+2;`, 100.0},
+		{`a = 12;`, 12},
+		{`a = 1.5;`, 1.5},
+		{`a = "1e-07";`, "1e-07"},
+	}
+	for _, tt := range tests {
+		l, err := parsePathLine(tt.line)
+		if err != nil {
+			t.Errorf("%s: %v", tt.line, err)
+			continue
+		}
+		if l.Value != tt.want {
+			t.Errorf("%s = %#v, want %#v", tt.line, l.Value, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -11,27 +12,109 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
-type SearchKey []string
+type SearchKey yamlconv.Path
 
 func (m *SearchKey) String() string {
-	return fmt.Sprint(*m)
+	return yamlconv.Path(*m).String()
 }
 
 func (m *SearchKey) Set(v string) error {
+	keys, err := yamlconv.ParsePath(v)
+	if err != nil {
+		return err
+	}
+	*m = append(*m, keys...)
+	return nil
+}
+
+type DeleteKeys []yamlconv.Path
+
+func (m *DeleteKeys) String() string {
+	return fmt.Sprint(*m)
+}
+
+func (m *DeleteKeys) Set(v string) error {
+	keys, err := yamlconv.ParsePath(v)
+	if err != nil {
+		return err
+	}
+	*m = append(*m, keys)
+	return nil
+}
+
+// subtract returns the yaml struct without the subtrees of the paths in order,
+// where a path not found is skipped, e.g. -d ..password without a password.
+func (m DeleteKeys) subtract(data interface{}) interface{} {
+	for _, keys := range m {
+		ret, err := yamlconv.Subtract(data, keys)
+		if errors.Is(err, yamlconv.ErrNotFoundError) {
+			continue
+		}
+		data = ret
+		if err != nil {
+			panic(fmt.Sprintf("ERROR: %s: %s\n", keys, err.Error()))
+		}
+	}
+	return data
+}
+
+type FileList []string
+
+func (m *FileList) String() string {
+	return fmt.Sprint(*m)
+}
+
+func (m *FileList) Set(v string) error {
 	*m = append(*m, v)
 	return nil
 }
 
-func main() {
-	var searchKeys SearchKey
-	yamlpath := flag.String("f", "/dev/stdin", "yaml file")
-	ofmt := flag.String("o", "text", "output format, one of yaml, json, text")
-	flag.Var(&searchKeys, "s", "define search keys multiple times, e.g. -s sriov -s [0] -s ip")
-	flag.Parse()
+type MergeKey yamlconv.MergeOptions
+
+func (m *MergeKey) String() string {
+	return fmt.Sprint(m.MergeKey, m.MergeKeys)
+}
+
+func (m *MergeKey) Set(v string) error {
+	list, key, found := strings.Cut(v, "=")
+	if !found {
+		m.MergeKey = v
+		return nil
+	}
+	if m.MergeKeys == nil {
+		m.MergeKeys = make(map[string]string)
+	}
+	m.MergeKeys[list] = key
+	return nil
+}
+
+// listStrategy returns the list merge strategy of the -merge-lists flag.
+func listStrategy(name string) yamlconv.ListStrategy {
+	switch name {
+	case "replace":
+		return yamlconv.ListReplace
+	case "append":
+		return yamlconv.ListAppend
+	case "key":
+		return yamlconv.ListMergeByKey
+	}
+	panic(fmt.Sprintf("ERROR: unknown merge strategy %s\n", name))
+}
 
+// mergeFiles returns the merge of the yaml files read in order.
+func mergeFiles(yamlpaths FileList, read func(string) interface{}, opts yamlconv.MergeOptions) interface{} {
+	data := read(yamlpaths[0])
+	for _, yamlpath := range yamlpaths[1:] {
+		data = yamlconv.Merge(data, read(yamlpath), opts)
+	}
+	return data
+}
+
+// readYaml returns the yaml struct of the yaml file.
+func readYaml(yamlpath string) interface{} {
 	// read yaml file into buffer
 	var filebuf []byte
-	filename, err := filepath.Abs(*yamlpath)
+	filename, err := filepath.Abs(yamlpath)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -65,25 +148,132 @@ func main() {
 	if err != nil {
 		panic(fmt.Sprintf("ERROR: %s\n", err.Error()))
 	}
+	return data
+}
 
-	data, err = yamlconv.Search(data, searchKeys)
-	if err != nil {
-		panic(fmt.Sprintf("ERROR: %s\n", err.Error()))
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		diffMain(os.Args[2:])
+		return
+	}
+
+	var searchKeys SearchKey
+	var yamlpaths FileList
+	var mergeKey MergeKey
+	var edits Edits
+	var deleteKeys DeleteKeys
+	flag.Var(&yamlpaths, "f", "yaml file (default \"/dev/stdin\"), multiple times to merge them in order")
+	ofmt := flag.String("o", "text", "output format, one of yaml, json, text, paths")
+	flag.Var(&searchKeys, "s", "define search path, e.g. -s sriov[0].ip, or keys multiple times, e.g. -s sriov -s [0] -s ip.\n"+
+		"wildcards * and [*] print all matches, e.g. -s sriov[*].ip")
+	flag.Var(&deleteKeys, "d", "remove path from the result of -s, multiple times, e.g. -d password or -d ..password,\n"+
+		"relative to each match of wildcards, applied in order after -s, skipping a path not found")
+	mergeLists := flag.String("merge-lists", "replace", "list merge strategy of multiple -f, one of replace, append, key")
+	flag.Var(&mergeKey, "merge-key", "list item key of -merge-lists key, e.g. -merge-key name,\n"+
+		"or of the lists under a map key multiple times, e.g. -merge-key sriov=network")
+	patchpath := flag.String("p", "", "JSON Patch file applied to the yaml, e.g. [{\"op\": \"remove\", \"path\": \"/password\"}]")
+	ungron := flag.Bool("ungron", false, "read -f files of the assignments printed by -o paths, e.g. sriov[0].ip = \"10.10.0.101\";")
+	flag.Var(SetEdit{&edits}, "set", "set the value of path, creating the missing keys, multiple times, e.g. -set service.type.NodePort=30090,\n"+
+		"where the value is a yaml, e.g. -set 'sriov[-]={network: resource02}'")
+	flag.Var(DeleteEdit{&edits}, "delete", "delete path, multiple times, e.g. -delete password.\n"+
+		"-set and -delete are applied to the whole yaml in order, before -s")
+	inplace := flag.Bool("i", false, "edit the -f file in place by -set and -delete, keeping its comments and format,\n"+
+		"without -s, -d, -o, -p, -ungron and -merge-*")
+	backup := flag.String("backup", "", "backup suffix of the file edited by -i, e.g. -backup .bak")
+	color := flag.String("color", "auto", "colour the output, one of auto, always, never")
+	flag.Parse()
+
+	if *inplace {
+		if len(yamlpaths) != 1 {
+			panic("ERROR: -i needs a single -f file\n")
+		}
+		// the flags of the output are not for the file edited
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "s", "d", "o", "p", "ungron", "merge-lists", "merge-key":
+				panic(fmt.Sprintf("ERROR: -i cannot be used with -%s\n", f.Name))
+			}
+		})
+		editFile(yamlpaths[0], edits, *backup)
+		return
+	}
+
+	if len(yamlpaths) == 0 {
+		yamlpaths = FileList{"/dev/stdin"}
+	}
+	opts := yamlconv.MergeOptions(mergeKey)
+	opts.Lists = listStrategy(*mergeLists)
+
+	read := readYaml
+	if *ungron {
+		read = readPaths
+	}
+
+	data := mergeFiles(yamlpaths, read, opts)
+
+	var err error
+	if len(*patchpath) > 0 {
+		data, err = yamlconv.ApplyJSONPatch(data, readYaml(*patchpath))
+		if err != nil {
+			panic(fmt.Sprintf("ERROR: %s\n", err.Error()))
+		}
 	}
+
+	data = edits.apply(data)
+
+	keys := yamlconv.Path(searchKeys)
+	var matches []yamlconv.Match
+	if keys.IsConcrete() {
+		sub, err := yamlconv.Search(data, keys)
+		if err != nil {
+			panic(fmt.Sprintf("ERROR: %s\n", err.Error()))
+		}
+		// the concrete keys of the selection, e.g. sriov[1] of sriov[-1]
+		path := keys
+		if found, err := yamlconv.SearchAll(data, keys); err == nil {
+			path = found[0].Path
+		}
+		data = deleteKeys.subtract(sub)
+		matches = []yamlconv.Match{{Path: path, Value: data}}
+	} else {
+		// print all matches, keyed by its path
+		matches, err = yamlconv.SearchAll(data, keys)
+		if err != nil {
+			panic(fmt.Sprintf("ERROR: %s\n", err.Error()))
+		}
+		found := yaml.MapSlice{}
+		for i, m := range matches {
+			m.Value = deleteKeys.subtract(m.Value)
+			matches[i] = m
+			found = append(found, yaml.MapItem{Key: m.Path.String(), Value: m.Value})
+		}
+		data = found
+	}
+	theme := colorTheme(*color)
 	switch *ofmt {
 	case "text":
-		yamlconv.Print(data, "  ")
+		p := &yamlconv.Printer{Indent: "  ", Types: true, Theme: theme}
+		p.Print(data)
 	case "json":
-		buf, err := yamlconv.MarshalJson(data, []string{})
-		if err != nil {
+		enc := yamlconv.NewEncoder(os.Stdout)
+		enc.SetTheme(theme)
+		if err := enc.Encode(data); err != nil {
 			panic(fmt.Sprintf("ERROR: %s\n", err.Error()))
 		}
-		os.Stdout.Write(buf)
 	case "yaml":
 		buf, err := yaml.Marshal(data)
 		if err != nil {
 			panic(fmt.Sprintf("ERROR: %s\n", err.Error()))
 		}
+		if theme != nil {
+			buf = colorYaml(buf, theme)
+		}
 		os.Stdout.Write(buf)
+	case "paths":
+		for _, m := range matches {
+			printPaths(os.Stdout, m.Path, m.Value)
+		}
+	default:
+		panic(fmt.Sprintf("ERROR: unknown output format %s\n", *ofmt))
 	}
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HaesungSeo/yamlconv"
+	"gopkg.in/yaml.v2"
+)
+
+// parseYaml returns the yaml struct of the yaml source text src.
+func parseYaml(t *testing.T, src string) interface{} {
+	t.Helper()
+	var data yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(src), &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// yamlText returns the yaml source text of the yaml struct data.
+func yamlText(t *testing.T, data interface{}) string {
+	t.Helper()
+	buf, err := yaml.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
+
+func TestDeleteKeys(t *testing.T) {
+	src := `
+sriov:
+- network: resource01
+  ip: 10.10.0.101
+- network: resource02
+password: centos
+`
+	tests := []struct {
+		paths []string
+		want  string
+	}{
+		{[]string{"password"}, "{sriov: [{network: resource01, ip: 10.10.0.101}, {network: resource02}]}"},
+		{[]string{"..ip", "password"}, "{sriov: [{network: resource01}, {network: resource02}]}"},
+		// a path not found is skipped
+		{[]string{"secret", "password"}, "{sriov: [{network: resource01, ip: 10.10.0.101}, {network: resource02}]}"},
+		{[]string{"..secret"}, src},
+		{[]string{"sriov[5]"}, src},
+		// a wildcard matching a part of the items
+		{[]string{"sriov[*].ip"}, "{sriov: [{network: resource01}, {network: resource02}], password: centos}"},
+		{[]string{"sriov[?network=='none']"}, src},
+	}
+	for _, tt := range tests {
+		var keys DeleteKeys
+		for _, path := range tt.paths {
+			if err := keys.Set(path); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got := yamlText(t, keys.subtract(parseYaml(t, src)))
+		if want := yamlText(t, parseYaml(t, tt.want)); got != want {
+			t.Errorf("-d %v:\n%s\nwant:\n%s", tt.paths, got, want)
+		}
+	}
+
+	// -d of each match of -s, where the second match has no ip
+	var keys DeleteKeys
+	keys.Set("ip")
+	matches, err := yamlconv.SearchAll(parseYaml(t, src), yamlconv.Path{"sriov", "[*]"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range matches {
+		got := yamlText(t, keys.subtract(m.Value))
+		if want := fmt.Sprintf("network: resource0%d\n", i+1); got != want {
+			t.Errorf("-s %s -d ip:\n%s\nwant:\n%s", m.Path, got, want)
+		}
+	}
+}
+
+func TestMergeFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	overlay := filepath.Join(dir, "overlay.yaml")
+	os.WriteFile(base, []byte("# base\nsriov:\n- network: a\n  mtu: 1500\nname: web\n"), 0644)
+	os.WriteFile(overlay, []byte("sriov:\n- network: a\n  mtu: 9000\n- network: b\n"), 0644)
+
+	tests := []struct {
+		flags []string // -merge-lists, and -merge-key
+		want  string
+	}{
+		{[]string{"replace"}, "{sriov: [{network: a, mtu: 9000}, {network: b}], name: web}"},
+		{[]string{"append"}, "{sriov: [{network: a, mtu: 1500}, {network: a, mtu: 9000}, {network: b}], name: web}"},
+		{[]string{"key", "network"}, "{sriov: [{network: a, mtu: 9000}, {network: b}], name: web}"},
+		{[]string{"replace", "sriov=network"}, "{sriov: [{network: a, mtu: 9000}, {network: b}], name: web}"},
+		{[]string{"append", "sriov=network"}, "{sriov: [{network: a, mtu: 9000}, {network: b}], name: web}"},
+		{[]string{"append", "other=network"}, "{sriov: [{network: a, mtu: 1500}, {network: a, mtu: 9000}, {network: b}], name: web}"},
+	}
+	for _, tt := range tests {
+		var mergeKey MergeKey
+		for _, v := range tt.flags[1:] {
+			if err := mergeKey.Set(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		opts := yamlconv.MergeOptions(mergeKey)
+		opts.Lists = listStrategy(tt.flags[0])
+		got := yamlText(t, mergeFiles(FileList{base, overlay}, readYaml, opts))
+		// readYaml reads the maps without the key order
+		var want interface{}
+		if err := yaml.Unmarshal([]byte(tt.want), &want); err != nil {
+			t.Fatal(err)
+		}
+		if want := yamlText(t, want); got != want {
+			t.Errorf("-merge-lists %v:\n%s\nwant:\n%s", tt.flags, got, want)
+		}
+	}
+}
+
+func TestMergeKeySet(t *testing.T) {
+	var m MergeKey
+	for _, v := range []string{"name", "sriov=network", "volumes=mountPath"} {
+		if err := m.Set(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.MergeKey != "name" || len(m.MergeKeys) != 2 ||
+		m.MergeKeys["sriov"] != "network" || m.MergeKeys["volumes"] != "mountPath" {
+		t.Errorf("-merge-key = %s, %v", m.MergeKey, m.MergeKeys)
+	}
+}
@@ -5,12 +5,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/HaesungSeo/yamlconv"
 	"gopkg.in/yaml.v2"
 )
 
+// search returns the sub yaml struct of data matching the path expression.
+func search(data interface{}, path string) (interface{}, error) {
+	keys, err := yamlconv.ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return yamlconv.Search(data, keys)
+}
+
 func crdObjects(data interface{}) (objs map[string]interface{}, err error) {
 	// check the it confirms crd definitions
 	fapiVersion := "apiVersion"
@@ -18,15 +26,15 @@ func crdObjects(data interface{}) (objs map[string]interface{}, err error) {
 	fmetaName := "metadata.name"
 	fspec := "spec"
 
-	_, err = yamlconv.Search(data, strings.Split(fapiVersion, "."))
+	_, err = search(data, fapiVersion)
 	if err != nil {
 		return nil, fmt.Errorf("NOT FOUND: %s: %+v", fapiVersion, err)
 	}
-	_, err = yamlconv.Search(data, strings.Split(fkind, "."))
+	_, err = search(data, fkind)
 	if err != nil {
 		return nil, fmt.Errorf("NOT FOUND: %s: %+vn", fkind, err)
 	}
-	mmeta, err := yamlconv.Search(data, strings.Split(fmetaName, "."))
+	mmeta, err := search(data, fmetaName)
 	if err != nil {
 		return nil, fmt.Errorf("NOT FOUND: %s: %+vn", fmetaName, err)
 	}
@@ -34,7 +42,7 @@ func crdObjects(data interface{}) (objs map[string]interface{}, err error) {
 	if !ok {
 		return nil, fmt.Errorf("CAST ERROR: %s", fmetaName)
 	}
-	_, err = yamlconv.Search(data, strings.Split(fspec, "."))
+	_, err = search(data, fspec)
 	if err != nil {
 		return nil, fmt.Errorf("NOT FOUND: %s: %+v", fspec, err)
 	}
@@ -51,19 +59,19 @@ func crdObjects(data interface{}) (objs map[string]interface{}, err error) {
 	fspecVers := "spec.versions"
 	fscheme := "schema.openAPIV3Schema.properties.spec.properties"
 
-	mSpecGrp, err := yamlconv.Search(data, strings.Split(fSpecGrp, "."))
+	mSpecGrp, err := search(data, fSpecGrp)
 	if err != nil {
 		return nil, fmt.Errorf("NOT FOUND: %s: %s: %+v", crdName, fSpecGrp, err)
 	}
-	mkind, err := yamlconv.Search(data, strings.Split(fspecKind, "."))
+	mkind, err := search(data, fspecKind)
 	if err != nil {
 		return nil, fmt.Errorf("NOT FOUND: %s: %s: %+v", crdName, fspecKind, err)
 	}
-	mname, err := yamlconv.Search(data, strings.Split(fspecName, "."))
+	mname, err := search(data, fspecName)
 	if err != nil {
 		return nil, fmt.Errorf("NOT FOUND: %s: %s: %+v", crdName, fspecName, err)
 	}
-	mvers, err := yamlconv.Search(data, strings.Split(fspecVers, "."))
+	mvers, err := search(data, fspecVers)
 	if err != nil {
 		return nil, fmt.Errorf("NOT FOUND: %s: %s: %+v", crdName, fspecVers, err)
 	}
@@ -77,7 +85,7 @@ func crdObjects(data interface{}) (objs map[string]interface{}, err error) {
 		if !ok {
 			return nil, fmt.Errorf("CAST ERROR: %s: spec.versions[%d]", crdName, ii)
 		}
-		mspec, err := yamlconv.Search(versionObj, strings.Split(fscheme, "."))
+		mspec, err := search(versionObj, fscheme)
 		if err != nil {
 			// don't panic, its common case
 			// return nil, fmt.Errorf("NOT FOUND: %s: [%d]%s: %+v", crdName, ii, fscheme, err)
@@ -0,0 +1,285 @@
+package yamlconv
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ChangeType is the type of a Change.
+type ChangeType int
+
+const (
+	// ChangeAdded is a sub yaml struct only in the new yaml struct.
+	ChangeAdded ChangeType = iota
+	// ChangeRemoved is a sub yaml struct only in the old yaml struct.
+	ChangeRemoved
+	// ChangeModified is a sub yaml struct of different values.
+	ChangeModified
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	}
+	return "unknown"
+}
+
+// Change is a difference between two yaml structs found by Diff.
+type Change struct {
+	Type ChangeType
+	// Path is the concrete keys of the sub yaml struct. The list index
+	// is the one in the old yaml struct, except for the list items added
+	// to the lists compared by key, which are at the end of list '[-]'.
+	Path Path
+	// Old is the sub yaml struct in the old yaml struct, or nil if added.
+	Old interface{}
+	// New is the sub yaml struct in the new yaml struct, or nil if removed.
+	New interface{}
+}
+
+// DiffOptions is the options of Diff.
+type DiffOptions struct {
+	// ListKeys is the map keys identifying the list items of the lists
+	// under the map keys, e.g. {"sriov": "network"}, which are compared
+	// by key regardless of its order. The other lists are compared
+	// position by position.
+	ListKeys map[string]string
+}
+
+// Diff returns the changes from the yaml struct a to the yaml struct b,
+// where the lists are compared position by position.
+// See DiffOptions.Diff for the details.
+func Diff(a, b interface{}) []Change {
+	return DiffOptions{}.Diff(a, b)
+}
+
+// Diff returns the changes from the yaml struct a to the yaml struct b,
+// in the order of the yaml structs.
+//
+// The golang maps are compared key by key regardless of the order of the
+// keys, sorted by its key name, followed by the new keys of b.
+// The yaml.MapSlice are compared by position, where the keys in the same
+// order in a and b are compared key by key, and the other keys are
+// removed from a and added from b, e.g. a key moved to another position
+// is both removed and added. A yaml.MapSlice and a golang map are
+// compared as golang maps.
+// The lists are compared position by position, or by key for the lists
+// in ListKeys. The numbers are compared by its value regardless of its
+// type.
+func (o DiffOptions) Diff(a, b interface{}) []Change {
+	d := &differ{opts: &o}
+	d.diff(a, b, Path{}, "")
+	return d.changes
+}
+
+type differ struct {
+	opts    *DiffOptions
+	changes []Change
+}
+
+func (d *differ) add(t ChangeType, path Path, old, new interface{}) {
+	d.changes = append(d.changes, Change{Type: t, Path: path, Old: old, New: new})
+}
+
+// diff collects the changes from a to b, where path is the concrete keys
+// of a, and name is the map key of a.
+func (d *differ) diff(a, b interface{}, path Path, name string) {
+	as, aok := a.(yaml.MapSlice)
+	bs, bok := b.(yaml.MapSlice)
+	if aok && bok {
+		d.diffOrdered(as, bs, path)
+		return
+	}
+
+	am, aok := mapItems(a)
+	bm, bok := mapItems(b)
+	if aok && bok {
+		for _, o := range am {
+			key := join(path, mapKey(keyName(o.Key)))
+			v, ok := mapGet(b, o.Key)
+			if !ok {
+				d.add(ChangeRemoved, key, o.Value, nil)
+				continue
+			}
+			d.diff(o.Value, v, key, keyName(o.Key))
+		}
+		for _, o := range bm {
+			if _, ok := mapGet(a, o.Key); !ok {
+				d.add(ChangeAdded, join(path, mapKey(keyName(o.Key))), nil, o.Value)
+			}
+		}
+		return
+	}
+
+	al, aok := a.([]interface{})
+	bl, bok := b.([]interface{})
+	if aok && bok {
+		if key, ok := d.opts.ListKeys[name]; ok {
+			d.diffByKey(al, bl, path, key)
+			return
+		}
+		for i := 0; i < len(al) || i < len(bl); i++ {
+			index := join(path, fmt.Sprintf("[%d]", i))
+			switch {
+			case i >= len(bl):
+				d.add(ChangeRemoved, index, al[i], nil)
+			case i >= len(al):
+				d.add(ChangeAdded, index, nil, bl[i])
+			default:
+				d.diff(al[i], bl[i], index, "")
+			}
+		}
+		return
+	}
+
+	if !equal(a, b) {
+		d.add(ChangeModified, path, a, b)
+	}
+}
+
+// diffOrdered collects the changes from the yaml.MapSlice a to b, where
+// the keys of the longest common sequence of the keys are compared by
+// value, and the other keys are removed or added.
+func (d *differ) diffOrdered(a, b yaml.MapSlice, path Path) {
+	kept := commonKeys(a, b)
+	for _, o := range a {
+		name := keyName(o.Key)
+		key := join(path, mapKey(name))
+		if !kept[name] {
+			d.add(ChangeRemoved, key, o.Value, nil)
+			continue
+		}
+		v, _ := mapGet(b, o.Key)
+		d.diff(o.Value, v, key, name)
+	}
+	for _, o := range b {
+		if name := keyName(o.Key); !kept[name] {
+			d.add(ChangeAdded, join(path, mapKey(name)), nil, o.Value)
+		}
+	}
+}
+
+// commonKeys returns the key names of the longest common sequence of the
+// keys of a and b.
+func commonKeys(a, b yaml.MapSlice) map[string]bool {
+	// n[i][j] is the length of the longest common sequence of a[i:] and b[j:]
+	n := make([][]int, len(a)+1)
+	for i := range n {
+		n[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case keyName(a[i].Key) == keyName(b[j].Key):
+				n[i][j] = n[i+1][j+1] + 1
+			case n[i+1][j] >= n[i][j+1]:
+				n[i][j] = n[i+1][j]
+			default:
+				n[i][j] = n[i][j+1]
+			}
+		}
+	}
+
+	kept := make(map[string]bool)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case keyName(a[i].Key) == keyName(b[j].Key):
+			kept[keyName(a[i].Key)] = true
+			i++
+			j++
+		case n[i+1][j] >= n[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return kept
+}
+
+// diffByKey collects the changes from the list a to the list b, where
+// the list items are identified by the map key.
+func (d *differ) diffByKey(a, b []interface{}, path Path, key string) {
+	used := make([]bool, len(b))
+	for i, o := range a {
+		index := join(path, fmt.Sprintf("[%d]", i))
+		j := indexByKey(b, o, key)
+		if j < 0 || used[j] {
+			d.add(ChangeRemoved, index, o, nil)
+			continue
+		}
+		used[j] = true
+		d.diff(o, b[j], index, "")
+	}
+	for j, o := range b {
+		if !used[j] {
+			d.add(ChangeAdded, join(path, "[-]"), nil, o)
+		}
+	}
+}
+
+// JSONPatch returns the JSON Patch of RFC 6902 of the changes found by
+// Diff, which patches the old yaml struct into the new one by
+// ApplyJSONPatch. The operations are yaml.MapSlice.
+//
+// The operations are ordered so that the list indexes are valid, i.e.
+// the modifications and additions first, the removals from the end of
+// the lists next, and the additions at the end of list '[-]' last.
+// A map key both removed and added, i.e. moved in yaml.MapSlice, is
+// removed before it is added, which ApplyJSONPatch appends to the
+// end of yaml.MapSlice.
+func JSONPatch(changes []Change) []interface{} {
+	added := make(map[string]bool)
+	for _, c := range changes {
+		if c.Type == ChangeAdded {
+			added[c.Path.String()] = true
+		}
+	}
+
+	var first, removed, last []Change
+	for _, c := range changes {
+		switch {
+		case c.Type == ChangeRemoved && !added[c.Path.String()]:
+			removed = append(removed, c)
+		case len(c.Path) > 0 && c.Path[len(c.Path)-1] == "[-]":
+			last = append(last, c)
+		default:
+			first = append(first, c)
+		}
+	}
+	sort.SliceStable(removed, func(i, j int) bool {
+		return comparePath(removed[i].Path, removed[j].Path) > 0
+	})
+
+	ops := make([]interface{}, 0, len(changes))
+	for _, list := range [][]Change{first, removed, last} {
+		for _, c := range list {
+			switch c.Type {
+			case ChangeAdded:
+				ops = append(ops, yaml.MapSlice{
+					{Key: "op", Value: "add"},
+					{Key: "path", Value: c.Path.Pointer()},
+					{Key: "value", Value: c.New},
+				})
+			case ChangeRemoved:
+				ops = append(ops, yaml.MapSlice{
+					{Key: "op", Value: "remove"},
+					{Key: "path", Value: c.Path.Pointer()},
+				})
+			case ChangeModified:
+				ops = append(ops, yaml.MapSlice{
+					{Key: "op", Value: "replace"},
+					{Key: "path", Value: c.Path.Pointer()},
+					{Key: "value", Value: c.New},
+				})
+			}
+		}
+	}
+	return ops
+}
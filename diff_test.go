@@ -0,0 +1,86 @@
+package yamlconv
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// changeText returns the changes in the text of "type path".
+func changeText(changes []Change) []string {
+	var ret []string
+	for _, c := range changes {
+		ret = append(ret, fmt.Sprintf("%s %s", c.Type, c.Path))
+	}
+	return ret
+}
+
+func TestDiffMapSlice(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []string
+		// the changes of the golang maps, compared regardless of the order
+		unordered []string
+	}{
+		{"same", "{p: 1, q: 2}", "{p: 1, q: 2}", nil, nil},
+		{"value", "{p: 1, q: 2}", "{p: 1, q: 3}",
+			[]string{"modified q"}, []string{"modified q"}},
+		{"swapped", "{p: 1, q: 2}", "{q: 2, p: 1}",
+			[]string{"removed p", "added p"}, nil},
+		{"moved to the end", "{p: 1, q: 2, r: 3}", "{q: 2, r: 3, p: 1}",
+			[]string{"removed p", "added p"}, nil},
+		{"moved and modified", "{p: 1, q: 2}", "{q: 3, p: 1}",
+			[]string{"removed p", "modified q", "added p"}, []string{"modified q"}},
+		{"inserted", "{p: 1, r: 3}", "{p: 1, q: 2, r: 3}",
+			[]string{"added q"}, []string{"added q"}},
+		{"removed", "{p: 1, q: 2, r: 3}", "{p: 1, r: 3}",
+			[]string{"removed q"}, []string{"removed q"}},
+		{"nested", "{m: {p: 1, q: 2}}", "{m: {q: 2, p: 1}}",
+			[]string{"removed m.p", "added m.p"}, nil},
+		{"in a list", "{l: [{p: 1, q: 2}]}", "{l: [{q: 2, p: 1}]}",
+			[]string{"removed l[0].p", "added l[0].p"}, nil},
+	}
+	for _, tt := range tests {
+		var a, b yaml.MapSlice
+		if err := yaml.Unmarshal([]byte(tt.a), &a); err != nil {
+			t.Fatal(err)
+		}
+		if err := yaml.Unmarshal([]byte(tt.b), &b); err != nil {
+			t.Fatal(err)
+		}
+		if got := changeText(Diff(a, b)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.name, got, tt.want)
+		}
+
+		var am, bm interface{}
+		if err := yaml.Unmarshal([]byte(tt.a), &am); err != nil {
+			t.Fatal(err)
+		}
+		if err := yaml.Unmarshal([]byte(tt.b), &bm); err != nil {
+			t.Fatal(err)
+		}
+		if got := changeText(Diff(am, bm)); !reflect.DeepEqual(got, tt.unordered) {
+			t.Errorf("%s: golang maps: %v, want %v", tt.name, got, tt.unordered)
+		}
+	}
+}
+
+func TestJSONPatchMovedKey(t *testing.T) {
+	var a, b yaml.MapSlice
+	if err := yaml.Unmarshal([]byte("{a: 1, m: {p: 1, q: 2}, c: 3}"), &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal([]byte("{m: {q: 2, p: 1}, c: 4, a: 1}"), &b); err != nil {
+		t.Fatal(err)
+	}
+	patched, err := ApplyJSONPatch(a, JSONPatch(Diff(a, b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Diff(patched, b); len(got) != 0 {
+		t.Errorf("patched %v, want %v: %v", patched, b, changeText(got))
+	}
+}
@@ -0,0 +1,843 @@
+package yamlconv
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Document is a yaml document for the round-trip editing, where the
+// edits by Set, Upsert and Subtract leave the comments, key order,
+// quoting style and blank lines of the untouched parts intact.
+//
+// An edit is applied to the source text directly, e.g. replacing the
+// text of a scalar, removing the lines of a map key or adding an entry
+// to a flow map, where a map or list emptied is written as {} or [].
+// An edit which cannot be applied to the source text, e.g. to a flow map
+// of multiple lines with comments, returns an InvalidEditError and leaves
+// the document unmodified.
+//
+// Only the first document of a multi-document source is edited.
+type Document struct {
+	src    []byte
+	root   *yamlv3.Node // the document node
+	lines  []int        // the offsets of the lines
+	indent int          // the indentation of the source
+}
+
+// ParseDocument returns the Document of the yaml source text.
+func ParseDocument(src []byte) (*Document, error) {
+	d := &Document{}
+	if err := d.parse(src); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Bytes returns the source text of the edited document.
+func (d *Document) Bytes() []byte {
+	return d.src
+}
+
+// Node returns the yaml.v3 document node of the edited document, e.g.
+// for Search. The node must not be modified.
+func (d *Document) Node() *yamlv3.Node {
+	return d.root
+}
+
+// Set replaces the sub yaml struct matching keys with value, as Set
+// does for the yaml structs. value is a yaml struct or *yaml.Node.
+func (d *Document) Set(keys Path, value interface{}) error {
+	return d.edit(func() error {
+		return d.setAll(keys, value, false)
+	})
+}
+
+// Upsert is like Set, but creates the missing maps and lists of keys,
+// as Upsert does for the yaml structs.
+func (d *Document) Upsert(keys Path, value interface{}) error {
+	return d.edit(func() error {
+		return d.setAll(keys, value, true)
+	})
+}
+
+// Subtract removes the sub yaml structs matching keys, as Subtract does
+// for the yaml structs.
+func (d *Document) Subtract(keys Path) error {
+	if len(keys) == 0 || len(keys[0]) == 0 {
+		return nil
+	}
+	return d.edit(func() error {
+		if keys.IsConcrete() {
+			return d.subtract(keys)
+		}
+
+		matches, err := SearchAll(d.root, keys)
+		if err != nil {
+			return err
+		}
+		for _, path := range subtractOrder(matches) {
+			if err := d.subtract(path); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// edit applies the edits of fn, or restores the document if fn fails.
+func (d *Document) edit(fn func() error) error {
+	src := d.src
+	if err := fn(); err != nil {
+		d.parse(src)
+		return err
+	}
+	return nil
+}
+
+func (d *Document) setAll(keys Path, value interface{}, create bool) error {
+	if keys.IsConcrete() {
+		return d.set(keys, value, create)
+	}
+
+	pattern, rest := splitPattern(keys)
+	matches, err := SearchAll(d.root, pattern)
+	if err != nil {
+		return err
+	}
+	for _, m := range matches {
+		if err := d.set(append(m.Path[:len(m.Path):len(m.Path)], rest...), value, create); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parse parses the source text src into the document.
+func (d *Document) parse(src []byte) error {
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(src, &root); err != nil {
+		return err
+	}
+	if root.Kind == 0 {
+		// an empty document
+		root = yamlv3.Node{Kind: yamlv3.DocumentNode}
+	}
+
+	d.src = src
+	d.root = &root
+	d.lines = []int{0}
+	d.indent = 0
+	for i, c := range src {
+		if c == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+	for n := range d.lines {
+		line := d.line(n + 1)
+		text := strings.TrimLeft(line, " ")
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+		if n := len(line) - len(text); n > 0 && (d.indent == 0 || n < d.indent) {
+			d.indent = n
+		}
+	}
+	if d.indent < 2 {
+		d.indent = 2
+	}
+	return nil
+}
+
+// line returns the text of the line n without the newline, where the
+// lines are numbered from 1.
+func (d *Document) line(n int) string {
+	if n < 1 || n > len(d.lines) {
+		return ""
+	}
+	line := d.src[d.lines[n-1]:]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	return strings.TrimSuffix(string(line), "\r")
+}
+
+// lineStart returns the offset of the line n, or the length of the
+// source text if there is no line n.
+func (d *Document) lineStart(n int) int {
+	if n < 1 || n > len(d.lines) {
+		return len(d.src)
+	}
+	return d.lines[n-1]
+}
+
+// offset returns the offset of the source position of the node.
+func (d *Document) offset(node *yamlv3.Node) int {
+	off := d.lineStart(node.Line)
+	for i := 1; i < node.Column && off < len(d.src); i++ {
+		_, size := utf8.DecodeRune(d.src[off:])
+		off += size
+	}
+	return off
+}
+
+// prefix returns the text of the line of the node before the node.
+func (d *Document) prefix(node *yamlv3.Node) string {
+	return string(d.src[d.lineStart(node.Line):d.offset(node)])
+}
+
+// entryEnd returns the line number next to the map entry or the list
+// item of the nodes, whose lines are indented more than indent.
+func (d *Document) entryEnd(indent int, nodes ...*yamlv3.Node) int {
+	last := 0
+	for _, node := range nodes {
+		if n := maxLine(node); n > last {
+			last = n
+		}
+	}
+	end := last + 1
+	for ; end <= len(d.lines); end++ {
+		line := d.line(end)
+		text := strings.TrimLeft(line, " ")
+		if len(text) > 0 && len(line)-len(text) <= indent || isDocBoundary(line) {
+			break
+		}
+	}
+	// leave the blank lines to the next one
+	for end-1 > last && len(strings.TrimSpace(d.line(end-1))) == 0 {
+		end--
+	}
+	return end
+}
+
+// isDocBoundary reports whether the line is the document start '---' or
+// the document end '...'.
+func isDocBoundary(line string) bool {
+	for _, mark := range []string{"---", "..."} {
+		if line == mark || strings.HasPrefix(line, mark+" ") || strings.HasPrefix(line, mark+"\t") {
+			return true
+		}
+	}
+	return false
+}
+
+// maxLine returns the last line of the nodes of the node and its
+// descendants, without following the aliases.
+func maxLine(node *yamlv3.Node) int {
+	n := node.Line
+	for _, o := range node.Content {
+		if m := maxLine(o); m > n {
+			n = m
+		}
+	}
+	return n
+}
+
+// entry is a map entry or a list item of a yaml.v3 node.
+type entry struct {
+	parent *yamlv3.Node // the resolved map or list
+	index  int          // the index of the key or the item in the content
+}
+
+func (e entry) isMap() bool {
+	return e.parent.Kind == yamlv3.MappingNode
+}
+
+// value returns the value node of the entry.
+func (e entry) value() *yamlv3.Node {
+	if e.isMap() {
+		return e.parent.Content[e.index+1]
+	}
+	return e.parent.Content[e.index]
+}
+
+// nodes returns the key and value nodes of a map entry, or the node of
+// a list item.
+func (e entry) nodes() []*yamlv3.Node {
+	if e.isMap() {
+		return e.parent.Content[e.index : e.index+2]
+	}
+	return e.parent.Content[e.index : e.index+1]
+}
+
+// indentOf returns the indentation of the entry, i.e. the column of the
+// map key or the '-' of the list item, and whether the entry starts its
+// line, i.e. only spaces are before the map key or the single '-'.
+func (d *Document) indentOf(e entry) (int, bool) {
+	prefix := d.prefix(e.parent.Content[e.index])
+	if e.isMap() {
+		return utf8.RuneCountInString(prefix), len(strings.TrimLeft(prefix, " ")) == 0
+	}
+	dash := strings.TrimRight(prefix, " ")
+	if !strings.HasSuffix(dash, "-") {
+		return utf8.RuneCountInString(prefix), false
+	}
+	return utf8.RuneCountInString(dash) - 1, strings.TrimLeft(dash, " ") == "-"
+}
+
+// isBlock reports whether the map or list node is in the block style.
+func isBlock(node *yamlv3.Node) bool {
+	return node.Style&yamlv3.FlowStyle == 0 && len(node.Content) > 0 &&
+		node.Content[0].Line > 0
+}
+
+// set replaces the sub yaml struct matching the concrete keys with value.
+func (d *Document) set(keys Path, value interface{}, create bool) error {
+	node, err := valueNode(value)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 || len(keys[0]) == 0 {
+		return d.replaceRoot(node)
+	}
+
+	// the deepest existing sub node
+	cur := d.root
+	var at entry
+	n := 0
+	for ; n < len(keys); n++ {
+		sub, _, idx, parent, err := nodeChild(cur, keys[n:])
+		if err != nil {
+			break
+		}
+		cur, at = sub, entry{parent, idx}
+	}
+
+	if n == len(keys) {
+		return d.replace(at, node)
+	}
+
+	st, err := parseStep(keys[n])
+	if err != nil {
+		return err
+	}
+	parent := resolveNode(cur)
+	if n < len(keys)-1 || (parent.Kind != yamlv3.MappingNode && parent.Kind != yamlv3.SequenceNode) {
+		if !create || (parent.Kind == yamlv3.ScalarNode && parent.Tag != "!!null") {
+			// only a null is replaced with a new map or list, as Upsert does
+			_, _, _, _, err := nodeChild(cur, keys[n:])
+			return err
+		}
+		if n < len(keys)-1 {
+			sub, err := Upsert(nil, keys[n+1:], value)
+			if err != nil {
+				return err
+			}
+			node, err = valueNode(sub)
+			if err != nil {
+				return err
+			}
+		}
+		if parent.Kind != yamlv3.MappingNode && parent.Kind != yamlv3.SequenceNode {
+			// replace the null with a new map or list
+			sub, err := Upsert(nil, keys[n:n+1], node)
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				return d.set(nil, sub, false)
+			}
+			return d.set(keys[:n], sub, false)
+		}
+	}
+
+	switch {
+	case parent.Kind == yamlv3.MappingNode && st.kind == keyStep:
+		return d.insert(parent, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: st.name}, node)
+	case parent.Kind == yamlv3.SequenceNode && st.kind == indexStep &&
+		(st.end || create && st.index == len(parent.Content)):
+		return d.insert(parent, nil, node)
+	}
+	_, _, _, _, err = nodeChild(cur, keys[n:])
+	return err
+}
+
+// replace replaces the value of the entry with the node.
+func (d *Document) replace(at entry, node *yamlv3.Node) error {
+	old := at.value()
+	var edit *splice
+	if text, ok := d.scalarText(old, node, at.parent); ok {
+		// replace the scalar text only, keeping the comment of the line
+		start := d.offset(old)
+		if end, ok := d.scalarEnd(old, start, !isBlock(at.parent)); ok {
+			edit = &splice{start, end, text}
+		}
+	}
+	if edit != nil {
+		// replaced
+	} else if isBlock(at.parent) {
+		indent, _ := d.indentOf(at)
+		start := d.offset(at.parent.Content[at.index])
+		end := d.lineStart(d.entryEnd(indent, at.nodes()...))
+		var text string
+		var err error
+		if at.isMap() {
+			key := *at.parent.Content[at.index]
+			key.HeadComment, key.LineComment, key.FootComment = "", "", ""
+			text, err = d.encode(&yamlv3.Node{Kind: yamlv3.MappingNode,
+				Content: []*yamlv3.Node{&key, node}})
+		} else {
+			text, err = d.encode(node)
+		}
+		if err == nil {
+			// the continued lines are aligned to the entry
+			col := utf8.RuneCountInString(d.prefix(at.parent.Content[at.index]))
+			text = indentLines(text, strings.Repeat(" ", col), false)
+			edit = &splice{start, end, text}
+		}
+	} else {
+		// the value in the flow map or list
+		start := d.offset(old)
+		end, ok := d.valueEnd(old)
+		text, err := d.flowText(nil, node)
+		if ok && err == nil {
+			edit = &splice{start, end, text}
+		}
+	}
+
+	if at.isMap() {
+		at.parent.Content[at.index+1] = node
+	} else {
+		at.parent.Content[at.index] = node
+	}
+	return d.commit(edit, old)
+}
+
+// replaceRoot replaces the content of the document with the node.
+func (d *Document) replaceRoot(node *yamlv3.Node) error {
+	text, err := d.encode(node)
+	if err != nil {
+		return err
+	}
+	var edit *splice
+	if len(d.root.Content) == 0 {
+		pos := len(d.src)
+		if pos > 0 && d.src[pos-1] != '\n' {
+			text = "\n" + text
+		}
+		edit = &splice{pos, pos, text}
+	} else {
+		old := d.root.Content[0]
+		start := d.offset(old)
+		edit = &splice{start, d.lineStart(d.entryEnd(-1, old)), text}
+	}
+	d.root.Content = []*yamlv3.Node{node}
+	return d.commit(edit, d.root)
+}
+
+// insert adds the map key of the node to the map node parent, or
+// appends the node to the list node parent, if key is nil.
+func (d *Document) insert(parent, key, node *yamlv3.Node) error {
+	var edit *splice
+	if isBlock(parent) {
+		var last, first entry
+		var text string
+		var err error
+		if key != nil {
+			last, first = entry{parent, len(parent.Content) - 2}, entry{parent, 0}
+			text, err = d.encode(&yamlv3.Node{Kind: yamlv3.MappingNode,
+				Content: []*yamlv3.Node{key, node}})
+		} else {
+			last, first = entry{parent, len(parent.Content) - 1}, entry{parent, 0}
+			text, err = d.encode(&yamlv3.Node{Kind: yamlv3.SequenceNode,
+				Content: []*yamlv3.Node{node}})
+		}
+		// the first key may follow the '-' of its list item
+		indent, _ := d.indentOf(first)
+		ok := key != nil || strings.HasSuffix(strings.TrimRight(d.prefix(parent.Content[0]), " "), "-")
+		if err == nil && ok {
+			lastIndent, _ := d.indentOf(last)
+			pos := d.lineStart(d.entryEnd(lastIndent, last.nodes()...))
+			text = indentLines(text, strings.Repeat(" ", indent), true)
+			if pos > 0 && d.src[pos-1] != '\n' {
+				text = "\n" + text
+			}
+			edit = &splice{pos, pos, text}
+		}
+	} else if parent.Style&yamlv3.FlowStyle != 0 {
+		// before the closing bracket of the flow map or list
+		start, end, ok := d.flowSpan(parent)
+		text, err := d.flowText(key, node)
+		if ok && err == nil {
+			if len(parent.Content) == 0 {
+				edit = &splice{start + 1, end - 1, text}
+			} else {
+				pos := d.trimSpace(start, end-1)
+				if d.src[pos-1] == ',' {
+					text = " " + text
+				} else {
+					text = ", " + text
+				}
+				edit = &splice{pos, pos, text}
+			}
+		}
+	}
+
+	if key != nil {
+		parent.Content = append(parent.Content, key, node)
+	} else {
+		parent.Content = append(parent.Content, node)
+	}
+	return d.commit(edit, parent)
+}
+
+// subtract removes the sub yaml struct matching the concrete keys.
+func (d *Document) subtract(keys Path) error {
+	cur := d.root
+	var at, up entry // the entry and the entry containing it
+	for n := range keys {
+		sub, _, idx, parent, err := nodeChild(cur, keys[n:])
+		if err != nil {
+			return err
+		}
+		cur, at, up = sub, entry{parent, idx}, at
+	}
+
+	var edit *splice
+	indent, first := d.indentOf(at)
+	size := len(at.nodes())
+	node := at.parent.Content[at.index]
+	if len(at.parent.Content) == size && isBlock(at.parent) {
+		// the map or list emptied is written in the flow style
+		empty := &yamlv3.Node{Kind: at.parent.Kind, Tag: at.parent.Tag, Style: yamlv3.FlowStyle,
+			Anchor: at.parent.Anchor}
+		switch {
+		case len(keys) == 1 && d.root.Content[0] == at.parent:
+			return d.replaceRoot(empty)
+		case len(keys) > 1 && up.value() == at.parent:
+			return d.replace(up, empty)
+		}
+	} else if isBlock(at.parent) && first {
+		start := at.parent.Content[at.index].Line
+		if at.parent.Content[at.index].HeadComment != "" {
+			// the comment lines of the entry
+			for start > 1 && strings.HasPrefix(strings.TrimSpace(d.line(start-1)), "#") {
+				start--
+			}
+		}
+		end := d.entryEnd(indent, at.nodes()...)
+		edit = &splice{d.lineStart(start), d.lineStart(end), ""}
+	} else if isBlock(at.parent) && at.index+size < len(at.parent.Content) {
+		// the next entry follows the '-' of the list item instead
+		start := d.offset(at.parent.Content[at.index])
+		end := d.offset(at.parent.Content[at.index+size])
+		edit = &splice{start, end, ""}
+	} else if !isBlock(at.parent) {
+		// the entry of the flow map or list with the comma
+		start, end, ok := d.flowSpan(at.parent)
+		from := d.offset(node)
+		switch {
+		case !ok:
+		case len(at.parent.Content) == size:
+			edit = &splice{start + 1, end - 1, ""}
+		case at.index+size < len(at.parent.Content):
+			edit = &splice{from, d.offset(at.parent.Content[at.index+size]), ""}
+		default:
+			// the comma after the previous entry, keeping its comment
+			prev, ok := d.valueEnd(at.parent.Content[at.index-1])
+			for ok && prev < from && (d.src[prev] == ' ' || d.src[prev] == '\t') {
+				prev++
+			}
+			if ok && d.src[prev] == ',' {
+				text := strings.TrimRight(string(d.src[prev+1:from]), " \t\r\n")
+				edit = &splice{prev, d.trimSpace(from, end-1), text}
+			}
+		}
+	}
+
+	at.parent.Content = append(at.parent.Content[:at.index], at.parent.Content[at.index+size:]...)
+	return d.commit(edit, node)
+}
+
+// splice is an edit of the source text, which replaces the text between
+// the offsets start and end with text.
+type splice struct {
+	start, end int
+	text       string
+}
+
+// commit updates the source text of the edited nodes by the edit, if it
+// is not nil and results in the same yaml struct as the edited nodes.
+// Otherwise it returns an InvalidEditError at the node.
+func (d *Document) commit(edit *splice, node *yamlv3.Node) error {
+	if edit != nil {
+		var b bytes.Buffer
+		b.Write(d.src[:edit.start])
+		b.WriteString(edit.text)
+		b.Write(d.src[edit.end:])
+
+		var root yamlv3.Node
+		if err := yamlv3.Unmarshal(b.Bytes(), &root); err == nil &&
+			len(Diff(nodeValue(d.root), nodeValue(&root))) == 0 {
+			return d.parse(b.Bytes())
+		}
+	}
+	return nodeError(&InvalidEditError{
+		fmt.Errorf("cannot edit the source text in place: %w",
+			ErrInvalidEditError)}, node)
+}
+
+// encode returns the yaml text of the node, indented as the source.
+func (d *Document) encode(node *yamlv3.Node) (string, error) {
+	var b bytes.Buffer
+	enc := yamlv3.NewEncoder(&b)
+	enc.SetIndent(d.indent)
+	if err := enc.Encode(node); err != nil {
+		return "", &InvalidValueError{
+			fmt.Errorf("cannot encode: %s: %w", err.Error(),
+				ErrInvalidValueError)}
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// scalarText returns the single line text of the scalar node replacing
+// the scalar node old, in the quoting style of old if both are strings.
+func (d *Document) scalarText(old, node, parent *yamlv3.Node) (string, bool) {
+	if old.Kind != yamlv3.ScalarNode || node.Kind != yamlv3.ScalarNode ||
+		old.Style&(yamlv3.LiteralStyle|yamlv3.FoldedStyle|yamlv3.TaggedStyle) != 0 {
+		return "", false
+	}
+	n := *node
+	if n.Tag == "!!str" && old.Tag == "!!str" && n.Style == 0 {
+		n.Style = old.Style & (yamlv3.DoubleQuotedStyle | yamlv3.SingleQuotedStyle)
+	}
+	if !isBlock(parent) && n.Style == 0 && n.Tag == "!!str" {
+		n.Style = yamlv3.DoubleQuotedStyle
+	}
+	text, err := d.encode(&n)
+	if err != nil {
+		return "", false
+	}
+	text = strings.TrimSuffix(text, "\n")
+	if strings.Contains(text, "\n") {
+		return "", false
+	}
+	return text, true
+}
+
+// scalarEnd returns the end offset of the single line scalar node at the
+// offset start, and whether it is found.
+func (d *Document) scalarEnd(node *yamlv3.Node, start int, flow bool) (int, bool) {
+	line := d.src[start:d.lineStart(node.Line+1)]
+	line = bytes.TrimRight(line, "\r\n")
+	var end int
+	switch {
+	case node.Style&yamlv3.DoubleQuotedStyle != 0:
+		end = 1
+		for end < len(line) && line[end] != '"' {
+			if line[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		end++
+	case node.Style&yamlv3.SingleQuotedStyle != 0:
+		end = 1
+		for end < len(line) && (line[end] != '\'' ||
+			end+1 < len(line) && line[end+1] == '\'') {
+			if line[end] == '\'' {
+				end++
+			}
+			end++
+		}
+		end++
+	default:
+		end = len(line)
+		if i := bytes.Index(line, []byte(" #")); i >= 0 {
+			end = i
+		}
+		if flow {
+			if i := bytes.IndexAny(line[:end], ",]}"); i >= 0 {
+				end = i
+			}
+		}
+		end = len(bytes.TrimRight(line[:end], " \t"))
+	}
+	if end > len(line) {
+		return 0, false
+	}
+
+	// the text must be the whole scalar
+	var v yamlv3.Node
+	if err := yamlv3.Unmarshal(line[:end], &v); err != nil ||
+		len(v.Content) != 1 || v.Content[0].Value != node.Value {
+		return 0, false
+	}
+	return start + end, true
+}
+
+// flowSpan returns the offsets of the opening bracket and next to the
+// closing bracket of the flow map or list node, after its anchor or tag,
+// and whether they are found.
+func (d *Document) flowSpan(node *yamlv3.Node) (int, int, bool) {
+	open := byte('{')
+	if node.Kind == yamlv3.SequenceNode {
+		open = '['
+	}
+	start := d.offset(node)
+	for start < len(d.src) && d.src[start] != open {
+		start++
+	}
+	end, ok := d.flowEnd(start)
+	return start, end, ok
+}
+
+// flowEnd returns the offset next to the closing bracket of the flow map
+// or list at the offset start, and whether it is found.
+func (d *Document) flowEnd(start int) (int, bool) {
+	depth := 0
+	prev := byte(',') // the last byte other than the spaces
+	for i := start; i < len(d.src); i++ {
+		c := d.src[i]
+		switch {
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+			if depth == 0 {
+				return i + 1, true
+			}
+		case (c == '"' || c == '\'') && strings.IndexByte("{[,:?", prev) >= 0:
+			// a quoted scalar
+			for i++; i < len(d.src) && d.src[i] != c; i++ {
+				if c == '"' && d.src[i] == '\\' {
+					i++
+				} else if c == '\'' && i+1 < len(d.src) && d.src[i+1] == '\'' {
+					i++
+				}
+			}
+		case c == '#' && i > start && strings.IndexByte(" \t\n", d.src[i-1]) >= 0:
+			// a comment
+			for i < len(d.src) && d.src[i] != '\n' {
+				i++
+			}
+		}
+		if i < len(d.src) && d.src[i] != ' ' && d.src[i] != '\t' {
+			prev = d.src[i]
+		}
+	}
+	return 0, false
+}
+
+// valueEnd returns the end offset of the scalar or the flow map or list
+// of the node in a flow map or list, and whether it is found.
+func (d *Document) valueEnd(node *yamlv3.Node) (int, bool) {
+	switch {
+	case node.Kind == yamlv3.ScalarNode:
+		return d.scalarEnd(node, d.offset(node), true)
+	case node.Style&yamlv3.FlowStyle != 0:
+		_, end, ok := d.flowSpan(node)
+		return end, ok
+	}
+	return 0, false
+}
+
+// trimSpace returns the offset end moved back over the spaces and the
+// newlines, but not before the offset start.
+func (d *Document) trimSpace(start, end int) int {
+	for end > start && strings.IndexByte(" \t\r\n", d.src[end-1]) >= 0 {
+		end--
+	}
+	return end
+}
+
+// flowText returns the text of the node in a flow list, or of the map
+// entry of key and the node in a flow map if key is not nil.
+func (d *Document) flowText(key, node *yamlv3.Node) (string, error) {
+	wrap := &yamlv3.Node{Kind: yamlv3.SequenceNode, Style: yamlv3.FlowStyle,
+		Content: []*yamlv3.Node{flowNode(node)}}
+	if key != nil {
+		wrap = &yamlv3.Node{Kind: yamlv3.MappingNode, Style: yamlv3.FlowStyle,
+			Content: []*yamlv3.Node{flowNode(key), flowNode(node)}}
+	}
+	text, err := d.encode(wrap)
+	if err != nil {
+		return "", err
+	}
+	// without the brackets
+	text = strings.TrimSpace(text)
+	return text[1 : len(text)-1], nil
+}
+
+// flowNode returns a copy of the node in the flow style without comments.
+func flowNode(node *yamlv3.Node) *yamlv3.Node {
+	ret := *node
+	ret.HeadComment, ret.LineComment, ret.FootComment = "", "", ""
+	switch node.Kind {
+	case yamlv3.MappingNode, yamlv3.SequenceNode:
+		ret.Style |= yamlv3.FlowStyle
+		ret.Content = make([]*yamlv3.Node, len(node.Content))
+		for i, o := range node.Content {
+			ret.Content[i] = flowNode(o)
+		}
+	case yamlv3.ScalarNode:
+		ret.Style &^= yamlv3.LiteralStyle | yamlv3.FoldedStyle
+	}
+	return &ret
+}
+
+// indentLines returns the lines of text indented by indent, where the
+// first line is indented only if first is true.
+func indentLines(text, indent string, first bool) string {
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if (i > 0 || first) && len(strings.TrimSpace(line)) > 0 {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// valueNode returns the yaml.v3 node of the yaml struct data.
+func valueNode(data interface{}) (*yamlv3.Node, error) {
+	switch m := data.(type) {
+	case *yamlv3.Node:
+		if m.Kind == yamlv3.DocumentNode && len(m.Content) > 0 {
+			return m.Content[0], nil
+		}
+		return m, nil
+	case []interface{}:
+		node := &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+		for _, o := range m {
+			sub, err := valueNode(o)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, sub)
+		}
+		return node, nil
+	case map[string]interface{}, map[interface{}]interface{}, yaml.MapSlice:
+		items, _ := mapItems(m)
+		node := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		for _, o := range items {
+			key, err := valueNode(o.Key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := valueNode(o.Value)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, key, value)
+		}
+		return node, nil
+	}
+
+	node := &yamlv3.Node{}
+	if err := node.Encode(data); err != nil {
+		return nil, &InvalidValueError{
+			fmt.Errorf("cannot encode %T: %s: %w", data, err.Error(),
+				ErrInvalidValueError)}
+	}
+	if node.Kind == yamlv3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	return node, nil
+}
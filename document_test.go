@@ -0,0 +1,235 @@
+package yamlconv
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// the edits of conv/sample.yaml, compared with the whole edited text
+func TestDocumentSample(t *testing.T) {
+	src, err := os.ReadFile("conv/sample.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		op    string
+		path  string
+		value interface{}
+		old   string // the lines of the source replaced by new
+		new   string
+	}{
+		// the line comments are kept
+		{"set", "sriov[0].ip", "10.10.0.201",
+			"    ip: 10.10.0.101     # network ip\n",
+			"    ip: 10.10.0.201     # network ip\n"},
+		{"upsert", "sriov[0].mtu", 9000,
+			"    ip: 10.10.0.101     # network ip\n",
+			"    ip: 10.10.0.101     # network ip\n    mtu: 9000\n"},
+		{"upsert", "sriov[-]", map[string]interface{}{"network": "resource02"},
+			"    ip: 10.10.0.101     # network ip\n",
+			"    ip: 10.10.0.101     # network ip\n  - network: resource02\n"},
+		{"set", "sriov[*].interface", "net2",
+			"    interface: net1\n",
+			"    interface: net2\n"},
+		// the last key of a map leaves an empty map
+		{"delete", "gpu.drivers", nil,
+			"gpu:\n drivers: video,compute,utility\n",
+			"gpu: {}\n"},
+		{"delete", "service.type.NodePort", nil,
+			"  type:\n    NodePort: 30080\n",
+			"  type: {}\n"},
+		// the blank line and the comment of the next key are kept
+		{"delete", "service", nil,
+			"service:\n  type:\n    NodePort: 30080\n\n",
+			"\n"},
+		{"delete", "ssh_pwauth", nil,
+			"ssh_pwauth: True\n",
+			""},
+		// the flow map stays a flow map
+		{"upsert", "chpasswd.list", "root",
+			"chpasswd: { expire: False }\n",
+			"chpasswd: { expire: False, list: root }\n"},
+		{"set", "chpasswd.expire", true,
+			"chpasswd: { expire: False }\n",
+			"chpasswd: { expire: true }\n"},
+		{"delete", "chpasswd.expire", nil,
+			"chpasswd: { expire: False }\n",
+			"chpasswd: {}\n"},
+		{"upsert", "chpasswd.users[-]", "root",
+			"chpasswd: { expire: False }\n",
+			"chpasswd: { expire: False, users: [root] }\n"},
+		// a scalar needing quotes is quoted
+		{"set", "password", "a: b",
+			"password: centos\n",
+			"password: 'a: b'\n"},
+	}
+	for _, tt := range tests {
+		name := tt.op + " " + tt.path
+		doc, err := ParseDocument(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys, err := ParsePath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch tt.op {
+		case "set":
+			err = doc.Set(keys, tt.value)
+		case "upsert":
+			err = doc.Upsert(keys, tt.value)
+		case "delete":
+			err = doc.Subtract(keys)
+		}
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		want := strings.Replace(string(src), tt.old, tt.new, 1)
+		if got := string(doc.Bytes()); got != want {
+			t.Errorf("%s:\n%s\nwant:\n%s", name, got, want)
+		}
+	}
+}
+
+const quotedYaml = `# quoting and block scalars
+name: "web"   # the app name
+single: 'it''s'
+script: |
+  echo a
+  echo b
+folded: >-
+  a long
+  line
+
+ports: [80, 443]  # flow list
+tail: end
+`
+
+func TestDocumentStyle(t *testing.T) {
+	tests := []struct {
+		op    string
+		path  string
+		value interface{}
+		old   string
+		new   string
+	}{
+		// the quoting of a string is kept
+		{"set", "name", "api",
+			`name: "web"   # the app name`,
+			`name: "api"   # the app name`},
+		{"set", "single", "it's not",
+			`single: 'it''s'`,
+			`single: 'it''s not'`},
+		// a block scalar is replaced as a whole
+		{"set", "script", "echo c",
+			"script: |\n  echo a\n  echo b\n",
+			"script: echo c\n"},
+		{"set", "script", "echo c\necho d\n",
+			"script: |\n  echo a\n  echo b\n",
+			"script: |\n  echo c\n  echo d\n"},
+		{"delete", "folded", nil,
+			"folded: >-\n  a long\n  line\n",
+			""},
+		// the flow list and its comment
+		{"delete", "ports[1]", nil,
+			"ports: [80, 443]  # flow list",
+			"ports: [80]  # flow list"},
+		{"delete", "ports[0]", nil,
+			"ports: [80, 443]  # flow list",
+			"ports: [443]  # flow list"},
+		{"upsert", "ports[-]", 8080,
+			"ports: [80, 443]  # flow list",
+			"ports: [80, 443, 8080]  # flow list"},
+		{"delete", "ports[*]", nil,
+			"ports: [80, 443]  # flow list",
+			"ports: []  # flow list"},
+		// the last key of the document
+		{"delete", "tail", nil,
+			"tail: end\n",
+			""},
+	}
+	for _, tt := range tests {
+		name := tt.op + " " + tt.path
+		doc, err := ParseDocument([]byte(quotedYaml))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys, err := ParsePath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch tt.op {
+		case "set":
+			err = doc.Set(keys, tt.value)
+		case "upsert":
+			err = doc.Upsert(keys, tt.value)
+		case "delete":
+			err = doc.Subtract(keys)
+		}
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		want := strings.Replace(quotedYaml, tt.old, tt.new, 1)
+		if got := string(doc.Bytes()); got != want {
+			t.Errorf("%s:\n%s\nwant:\n%s", name, got, want)
+		}
+	}
+}
+
+// the errors of Document are those of the yaml structs, and the document
+// is not modified by a failed edit
+func TestDocumentError(t *testing.T) {
+	tests := []struct {
+		src  string
+		op   string
+		path string
+		err  error
+	}{
+		{"a: 1\n", "upsert", "a.new", ErrSearchKeyTooLongError},
+		{"a: 1\n", "upsert", "a[-]", ErrSearchKeyTooLongError},
+		{"b: [x, y]\n", "upsert", "b[0].new", ErrSearchKeyTooLongError},
+		{"a: 1\n", "set", "b.c", ErrNotFoundError},
+		{"a: 1\n", "delete", "b", ErrNotFoundError},
+		// the alias cannot be edited without its anchor
+		{"z: &z\n  q: 1\nw: *z\n", "delete", "w.q", ErrInvalidEditError},
+		// the second edit fails, and the first is undone
+		{"a: {p: 1}\nb: 2\n", "upsert", "*.q", ErrSearchKeyTooLongError},
+	}
+	for _, tt := range tests {
+		name := tt.op + " " + tt.path
+		doc, err := ParseDocument([]byte(tt.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys, err := ParsePath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data interface{} = parseYaml(t, tt.src)
+		var want error
+		switch tt.op {
+		case "set":
+			err = doc.Set(keys, 9)
+			_, want = Set(data, keys, 9)
+		case "upsert":
+			err = doc.Upsert(keys, 9)
+			_, want = Upsert(data, keys, 9)
+		case "delete":
+			err = doc.Subtract(keys)
+			_, want = Subtract(data, keys)
+		}
+		if tt.err == ErrInvalidEditError {
+			want = tt.err
+		}
+		if !errors.Is(err, tt.err) || !errors.Is(want, tt.err) {
+			t.Errorf("%s: %v, want %v as the yaml struct: %v", name, err, tt.err, want)
+		}
+		if got := string(doc.Bytes()); got != tt.src {
+			t.Errorf("%s: the document is modified:\n%s", name, got)
+		}
+	}
+}
@@ -0,0 +1,598 @@
+package yamlconv
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Set returns the modified yaml struct data, where the sub yaml struct
+// matching keys is replaced with value.
+// a key in keys must be a form described in Path.
+//
+// The last key may be a new map key, which is added to the map, or the
+// end of list index '[-]', which appends value to the list, while the
+// other keys must exist. Use Upsert to create them.
+// It returns the value, if the keys is empty.
+// If the keys have a wildcard, the keys up to the last wildcard are
+// searched by SearchAll, and the rest of the keys are set in each match,
+// e.g. "sriov[*].mtu" adds the key mtu to all the list items.
+//
+// The maps and lists of data are modified in place.
+//
+// An error is returned if there are no match keys or the length
+// of keys are longer than the one of nesting of yaml struct data.
+func Set(data interface{}, keys Path, value interface{}) (interface{}, error) {
+	return setAll(data, keys, value, false)
+}
+
+// Upsert is like Set, but creates the missing maps and lists of keys.
+//
+// A created map is the same type as the map containing it, i.e. one of
+// yaml.MapSlice, map[interface{}]interface{} and map[string]interface{},
+// or yaml.MapSlice if there is none. A list is created for an index key,
+// and a list item is appended for the index of the length of the list.
+func Upsert(data interface{}, keys Path, value interface{}) (interface{}, error) {
+	return setAll(data, keys, value, true)
+}
+
+func setAll(data interface{}, keys Path, value interface{}, create bool) (interface{}, error) {
+	if keys.IsConcrete() {
+		return set(data, keys, value, create, data)
+	}
+
+	pattern, rest := splitPattern(keys)
+	matches, err := SearchAll(data, pattern)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range matches {
+		data, err = set(data, append(m.Path[:len(m.Path):len(m.Path)], rest...), value, create, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// set returns the modified yaml struct data, where the sub yaml struct
+// matching the concrete keys is replaced with value.
+// proto is the map containing data, which is the prototype of the maps
+// to create.
+func set(data interface{}, keys Path, value interface{}, create bool, proto interface{}) (interface{}, error) {
+	if len(keys) == 0 || len(keys[0]) == 0 {
+		// the end of search
+		return value, nil
+	}
+
+	// index or pattern
+	st, err := parseStep(keys[0])
+	if err != nil {
+		return nil, err
+	}
+	idx := st.index
+	search := st.name
+	isIndex := st.kind == indexStep
+
+	if data == nil && create {
+		if isIndex {
+			data = []interface{}{}
+		} else {
+			data = newMap(proto)
+		}
+	}
+
+	switch m := data.(type) {
+	case []interface{}:
+		if !isIndex {
+			return nil, &InvalidIndexError{
+				fmt.Errorf("expect key[%s], but []interface{}: %w", search,
+					ErrInvalidIndexError)}
+		}
+		if st.end || (create && idx == len(m)) {
+			ret, err := set(nil, keys[1:], value, create, proto)
+			if err != nil {
+				return nil, err
+			}
+			return append(m, ret), nil
+		}
+		idx, err = listIndex(idx, len(m), "arr")
+		if err != nil {
+			return nil, err
+		}
+		ret, err := set(m[idx], keys[1:], value, create, proto)
+		if err != nil {
+			return nil, err
+		}
+		m[idx] = ret
+		return m, nil
+	case map[string]interface{}:
+		if isIndex {
+			return nil, &InvalidIndexError{
+				fmt.Errorf("expect index %d, but map[]interface{}: %w", idx,
+					ErrInvalidIndexError)}
+		}
+		i, ok := m[search]
+		if !ok && !create && len(keys) > 1 {
+			var mkeys []interface{}
+			for k := range m {
+				mkeys = append(mkeys, k)
+			}
+			return nil, &NotFoundError{
+				fmt.Errorf("search %s not in %s: %w", search, mkeys,
+					ErrNotFoundError)}
+		}
+		ret, err := set(i, keys[1:], value, create, m)
+		if err != nil {
+			return nil, err
+		}
+		m[search] = ret
+		return m, nil
+	case map[interface{}]interface{}:
+		if isIndex {
+			return nil, &InvalidIndexError{
+				fmt.Errorf("expect index %d, but map[]interface{}: %w", idx,
+					ErrInvalidIndexError)}
+		}
+		k, ok := lookup(m, search)
+		if !ok {
+			if !create && len(keys) > 1 {
+				var mkeys []interface{}
+				for k := range m {
+					mkeys = append(mkeys, k)
+				}
+				return nil, &NotFoundError{
+					fmt.Errorf("search %s not in %s: %w", search, mkeys,
+						ErrNotFoundError)}
+			}
+			k = search
+		}
+		ret, err := set(m[k], keys[1:], value, create, m)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = ret
+		return m, nil
+	case yaml.MapSlice:
+		if isIndex {
+			if st.end || (create && idx == len(m)) {
+				return nil, &InvalidIndexError{
+					fmt.Errorf("cannot create index %s of MapSlice: %w", keys[0],
+						ErrInvalidIndexError)}
+			}
+			idx, err = listIndex(idx, len(m), "MapSlice")
+			if err != nil {
+				return nil, err
+			}
+			ret, err := set(m[idx].Value, keys[1:], value, create, m)
+			if err != nil {
+				return nil, err
+			}
+			m[idx].Value = ret
+			return m, nil
+		}
+		var mkeys []interface{}
+		for idx, v := range m {
+			if keyMatch(v.Key, search) {
+				ret, err := set(v.Value, keys[1:], value, create, m)
+				if err != nil {
+					return nil, err
+				}
+				m[idx].Value = ret
+				return m, nil
+			}
+			mkeys = append(mkeys, v.Key)
+		}
+		if !create && len(keys) > 1 {
+			return nil, &NotFoundError{
+				fmt.Errorf("search %s not in %s: %w", search, mkeys,
+					ErrNotFoundError)}
+		}
+		ret, err := set(nil, keys[1:], value, create, m)
+		if err != nil {
+			return nil, err
+		}
+		return append(m, yaml.MapItem{Key: search, Value: ret}), nil
+	case nil:
+		return nil, &NotFoundError{
+			fmt.Errorf("search %s not in null: %w", keys[0],
+				ErrNotFoundError)}
+	default:
+		return nil, &SearchKeyTooLongError{
+			fmt.Errorf("key left: %s: %w", keys,
+				ErrSearchKeyTooLongError)}
+	}
+}
+
+// newMap returns a new empty map of the same type as the map proto,
+// or yaml.MapSlice if proto is not a map.
+func newMap(proto interface{}) interface{} {
+	switch proto.(type) {
+	case map[string]interface{}:
+		return map[string]interface{}{}
+	case map[interface{}]interface{}:
+		return map[interface{}]interface{}{}
+	}
+	return yaml.MapSlice{}
+}
+
+// Insert returns the modified yaml struct data, where value is inserted
+// into the list matching keys before the list item of index.
+// a key in keys must be a form described in Path.
+//
+// The list is either []interface{} or yaml.MapSlice, where value must be
+// a yaml.MapItem with a new map key. A null is an empty list.
+// index is in the range of [-len, len], where a negative index counts
+// from the end of the list as in python, and len appends value.
+// If the keys match more than one list, e.g. with a wildcard, value is
+// inserted into all the lists found by SearchAll.
+//
+// An error is returned if there are no match keys, the match is not
+// a list or the index is out of range.
+func Insert(data interface{}, keys Path, index int, value interface{}) (interface{}, error) {
+	return update(data, keys, func(list interface{}) (interface{}, error) {
+		return insert(list, index, false, []interface{}{value})
+	})
+}
+
+// Append returns the modified yaml struct data, where values are
+// appended to the list matching keys.
+// It is the same as Insert with the index of the length of the list,
+// or Set with the end of list index '[-]' for each value.
+func Append(data interface{}, keys Path, values ...interface{}) (interface{}, error) {
+	return update(data, keys, func(list interface{}) (interface{}, error) {
+		return insert(list, 0, true, values)
+	})
+}
+
+// update returns the modified yaml struct data, where each sub yaml
+// struct matching keys is replaced with the result of fn.
+func update(data interface{}, keys Path, fn func(interface{}) (interface{}, error)) (interface{}, error) {
+	var paths []Path
+	if keys.IsConcrete() {
+		paths = []Path{keys}
+	} else {
+		matches, err := SearchAll(data, keys)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			paths = append(paths, m.Path)
+		}
+	}
+
+	for _, path := range paths {
+		sub, err := Search(data, path)
+		if err != nil {
+			return nil, err
+		}
+		ret, err := fn(sub)
+		if err != nil {
+			return nil, err
+		}
+		data, err = Set(data, path, ret)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// insert returns the new list, where values are inserted into the list
+// before the list item of index, or appended if end is true.
+func insert(list interface{}, index int, end bool, values []interface{}) (interface{}, error) {
+	if list == nil {
+		list = []interface{}{}
+	}
+
+	switch m := list.(type) {
+	case []interface{}:
+		if end {
+			index = len(m)
+		}
+		pos, err := insertIndex(index, len(m), "arr")
+		if err != nil {
+			return nil, err
+		}
+		ret := make([]interface{}, 0, len(m)+len(values))
+		ret = append(ret, m[:pos]...)
+		ret = append(ret, values...)
+		return append(ret, m[pos:]...), nil
+	case yaml.MapSlice:
+		if end {
+			index = len(m)
+		}
+		pos, err := insertIndex(index, len(m), "MapSlice")
+		if err != nil {
+			return nil, err
+		}
+		ret := make(yaml.MapSlice, 0, len(m)+len(values))
+		ret = append(ret, m[:pos]...)
+		for _, v := range values {
+			item, ok := v.(yaml.MapItem)
+			if !ok {
+				return nil, &InvalidValueError{
+					fmt.Errorf("expect yaml.MapItem, but %T: %w", v,
+						ErrInvalidValueError)}
+			}
+			for _, o := range m {
+				if o.Key == item.Key {
+					return nil, &InvalidValueError{
+						fmt.Errorf("key %v already in MapSlice: %w", item.Key,
+							ErrInvalidValueError)}
+				}
+			}
+			ret = append(ret, item)
+		}
+		return append(ret, m[pos:]...), nil
+	default:
+		return nil, &InvalidIndexError{
+			fmt.Errorf("expect list, but %T: %w", list,
+				ErrInvalidIndexError)}
+	}
+}
+
+// insertIndex returns the position to insert into a list of length n
+// before the list item of index, where a negative index counts from the
+// end of the list and n is the end of the list.
+// kind is the type name of the list used in the error message.
+func insertIndex(index, n int, kind string) (int, error) {
+	pos := index
+	if pos < 0 {
+		pos += n
+	}
+	if pos < 0 || pos > n {
+		return 0, &IndexOutOfRangeError{
+			fmt.Errorf("index %d out of len(%s) %d: %w", index, kind, n,
+				ErrIndexOutOfRangeError)}
+	}
+	return pos, nil
+}
+
+// Rename returns the modified yaml struct data, where the map key of the
+// sub yaml struct matching keys is renamed to newKey.
+// a key in keys must be a form described in Path, and the last key must
+// be a map key. The renamed key keeps its position in yaml.MapSlice.
+//
+// An error is returned if there are no match keys, the last key is an
+// index or newKey is already in the map.
+func Rename(data interface{}, keys Path, newKey string) (interface{}, error) {
+	if len(keys) == 0 {
+		return nil, &InvalidIndexError{
+			fmt.Errorf("no key to rename: %w",
+				ErrInvalidIndexError)}
+	}
+	st, err := parseStep(keys[len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+	if st.kind != keyStep {
+		return nil, &InvalidIndexError{
+			fmt.Errorf("expect key, but %s: %w", keys[len(keys)-1],
+				ErrInvalidIndexError)}
+	}
+
+	parent := keys[:len(keys)-1]
+	return update(data, parent, func(sub interface{}) (interface{}, error) {
+		return rename(sub, st.name, newKey)
+	})
+}
+
+// rename returns the map m, where the map key name is renamed to newKey.
+func rename(m interface{}, name, newKey string) (interface{}, error) {
+	if name == newKey {
+		_, _, err := child(m, Path{mapKey(name)})
+		return m, err
+	}
+	if _, _, err := child(m, Path{mapKey(newKey)}); err == nil {
+		return nil, &InvalidIndexError{
+			fmt.Errorf("key %s already in map: %w", newKey,
+				ErrInvalidIndexError)}
+	}
+	if _, _, err := child(m, Path{mapKey(name)}); err != nil {
+		return nil, err
+	}
+
+	switch m := m.(type) {
+	case map[string]interface{}:
+		m[newKey] = m[name]
+		delete(m, name)
+	case map[interface{}]interface{}:
+		k, _ := lookup(m, name)
+		m[newKey] = m[k]
+		delete(m, k)
+	case yaml.MapSlice:
+		for i, o := range m {
+			if keyMatch(o.Key, name) {
+				m[i].Key = newKey
+				break
+			}
+		}
+	}
+	return m, nil
+}
+
+// Move returns the modified yaml struct data, where the sub yaml struct
+// matching the keys from is moved to the keys to.
+// a key in from and to must be a form described in Path, and both must
+// match one sub yaml struct. The sub yaml struct is subtracted first,
+// and then set to the keys to as Upsert does, except that a list index
+// of the last key inserts it before the list item of the index, or
+// appends it with '[-]', as the move of JSON Patch does. So the index
+// is the one in the list after the subtraction, e.g. moving "a[0]" to
+// "a[2]" of the list a [x, y, z] results in [y, z, x].
+// The other list indexes of to are the ones in data.
+//
+// An error is returned if there are no match keys from, or the keys to
+// is in the sub yaml struct to move. data is not modified on error.
+func Move(data interface{}, from, to Path) (interface{}, error) {
+	if !from.IsConcrete() || !to.IsConcrete() {
+		return nil, &InvalidIndexError{
+			fmt.Errorf("expect concrete keys, but %s to %s: %w", from, to,
+				ErrInvalidIndexError)}
+	}
+	if len(to) > len(from) && comparePath(to[:len(from)], from) == 0 {
+		return nil, &InvalidIndexError{
+			fmt.Errorf("cannot move %s into itself %s: %w", from, to,
+				ErrInvalidIndexError)}
+	}
+	matches, err := SearchAll(data, from)
+	if err != nil {
+		return nil, err
+	}
+	if comparePath(from, to) == 0 && to[len(to)-1] != "[-]" {
+		return data, nil
+	}
+	to, err = movedPath(data, matches[0].Path, to)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := deepCopy(data)
+	sub, err := Search(ret, from)
+	if err != nil {
+		return nil, err
+	}
+	ret, err = Subtract(ret, from)
+	if err != nil {
+		return nil, err
+	}
+
+	// a list index inserts the sub yaml struct into the list
+	if n := len(to) - 1; n >= 0 {
+		st, err := parseStep(to[n])
+		if err == nil && st.kind == indexStep {
+			list, err := Search(ret, to[:n])
+			if err == nil {
+				switch m := list.(type) {
+				case nil, []interface{}:
+					if st.end {
+						return Append(ret, to[:n], sub)
+					}
+					return Insert(ret, to[:n], st.index, sub)
+				case *yamlv3.Node:
+					if !st.end && resolveNode(m).Kind == yamlv3.SequenceNode {
+						return nil, &InvalidIndexError{
+							fmt.Errorf("cannot insert into the yaml.v3 list %s, but [-] to append: %w",
+								to[:n], ErrInvalidIndexError)}
+					}
+				}
+			}
+		}
+	}
+	return Upsert(ret, to, sub)
+}
+
+// movedPath returns the keys to in data after the sub yaml struct of
+// the concrete keys from is subtracted, where the list index of to in
+// the list of from, other than the last key, is shifted if it is after
+// the one of from.
+func movedPath(data interface{}, from, to Path) (Path, error) {
+	p := len(from) - 1
+	if p < 0 || len(to) <= p+1 {
+		return to, nil
+	}
+	st, err := parseStep(from[p])
+	if err != nil || st.kind != indexStep {
+		return to, nil
+	}
+
+	// the concrete index of to in the same list
+	matches, err := SearchAll(data, to[:p+1])
+	if err != nil || comparePath(matches[0].Path[:p], from[:p]) != 0 {
+		return to, nil
+	}
+	at, err := parseStep(matches[0].Path[p])
+	if err != nil || at.kind != indexStep {
+		return to, nil
+	}
+	switch {
+	case at.index == st.index:
+		return nil, &InvalidIndexError{
+			fmt.Errorf("cannot move %s into itself %s: %w", from, to,
+				ErrInvalidIndexError)}
+	case at.index > st.index:
+		ret := append(Path{}, to...)
+		ret[p] = fmt.Sprintf("[%d]", at.index-1)
+		return ret, nil
+	}
+	return to, nil
+}
+
+// Copy returns the modified yaml struct data, where a deep copy of the
+// sub yaml struct matching the keys from is set to the keys to as
+// Upsert does.
+// a key in from and to must be a form described in Path, and both must
+// match one sub yaml struct.
+//
+// An error is returned if there are no match keys from.
+func Copy(data interface{}, from, to Path) (interface{}, error) {
+	if !from.IsConcrete() || !to.IsConcrete() {
+		return nil, &InvalidIndexError{
+			fmt.Errorf("expect concrete keys, but %s to %s: %w", from, to,
+				ErrInvalidIndexError)}
+	}
+	sub, err := Search(data, from)
+	if err != nil {
+		return nil, err
+	}
+	return Upsert(data, to, deepCopy(sub))
+}
+
+// deepCopy returns a copy of the yaml struct data, which shares no
+// maps and lists with data.
+func deepCopy(data interface{}) interface{} {
+	switch m := data.(type) {
+	case []interface{}:
+		ret := make([]interface{}, len(m))
+		for i, o := range m {
+			ret[i] = deepCopy(o)
+		}
+		return ret
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			ret[k] = deepCopy(v)
+		}
+		return ret
+	case map[interface{}]interface{}:
+		ret := make(map[interface{}]interface{}, len(m))
+		for k, v := range m {
+			ret[k] = deepCopy(v)
+		}
+		return ret
+	case yaml.MapSlice:
+		ret := make(yaml.MapSlice, len(m))
+		for i, o := range m {
+			ret[i] = yaml.MapItem{Key: o.Key, Value: deepCopy(o.Value)}
+		}
+		return ret
+	case *yamlv3.Node:
+		return copyNode(m, map[*yamlv3.Node]*yamlv3.Node{})
+	default:
+		return data
+	}
+}
+
+// copyNode returns a copy of the yaml.v3 node and its descendants, where
+// copied is the copies of the nodes so far, to keep the aliases linked.
+func copyNode(node *yamlv3.Node, copied map[*yamlv3.Node]*yamlv3.Node) *yamlv3.Node {
+	if node == nil {
+		return nil
+	}
+	if ret, ok := copied[node]; ok {
+		return ret
+	}
+	ret := &yamlv3.Node{}
+	*ret = *node
+	copied[node] = ret
+	if node.Content != nil {
+		ret.Content = make([]*yamlv3.Node, len(node.Content))
+		for i, o := range node.Content {
+			ret.Content[i] = copyNode(o, copied)
+		}
+	}
+	ret.Alias = copyNode(node.Alias, copied)
+	return ret
+}
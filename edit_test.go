@@ -0,0 +1,272 @@
+package yamlconv
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// parseYaml returns the yaml struct of the yaml source text src.
+func parseYaml(t *testing.T, src string) interface{} {
+	t.Helper()
+	var data yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(src), &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// yamlText returns the yaml source text of the yaml struct data.
+func yamlText(t *testing.T, data interface{}) string {
+	t.Helper()
+	buf, err := yaml.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
+
+const sriovYaml = `
+sriov:
+- network: resource01
+  ip: 10.10.0.101
+- network: resource02
+spec:
+  template:
+    metadata:
+      name: a
+  metadata:
+    name: b
+`
+
+func TestSetPattern(t *testing.T) {
+	tests := []struct {
+		path   string
+		upsert bool
+		want   string
+	}{
+		{"sriov[*].mtu", false, `sriov:
+- network: resource01
+  ip: 10.10.0.101
+  mtu: 9000
+- network: resource02
+  mtu: 9000
+`},
+		{"sriov[*].ip", true, `sriov:
+- network: resource01
+  ip: 9000
+- network: resource02
+  ip: 9000
+`},
+		{"sriov[?network=='resource02'].mtu", false, `sriov:
+- network: resource01
+  ip: 10.10.0.101
+- network: resource02
+  mtu: 9000
+`},
+		{"sriov[*].opts.mtu", true, `sriov:
+- network: resource01
+  ip: 10.10.0.101
+  opts:
+    mtu: 9000
+- network: resource02
+  opts:
+    mtu: 9000
+`},
+		{"spec.*.mtu", false, `spec:
+  template:
+    metadata:
+      name: a
+    mtu: 9000
+  metadata:
+    name: b
+    mtu: 9000
+`},
+		{"..metadata.labels.app", true, `spec:
+  template:
+    metadata:
+      name: a
+      labels:
+        app: 9000
+  metadata:
+    name: b
+    labels:
+      app: 9000
+`},
+	}
+	for _, tt := range tests {
+		keys, err := ParsePath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := parseYaml(t, sriovYaml)
+		if tt.upsert {
+			data, err = Upsert(data, keys, 9000)
+		} else {
+			data, err = Set(data, keys, 9000)
+		}
+		if err != nil {
+			t.Errorf("set %s: %v", tt.path, err)
+			continue
+		}
+		// compare the part of the yaml under the top key edited
+		top := keys[0]
+		if top == ".." {
+			top = "spec"
+		}
+		sub, _ := Search(data, Path{top})
+		got := yamlText(t, yaml.MapSlice{{Key: top, Value: sub}})
+		if got != tt.want {
+			t.Errorf("set %s:\n%s\nwant:\n%s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSetPatternError(t *testing.T) {
+	tests := []struct {
+		path string
+		err  error
+	}{
+		// the keys other than the last must exist for Set
+		{"sriov[*].opts.mtu", ErrNotFoundError},
+		// the pattern must match
+		{"sriov[?network=='none'].mtu", ErrNotFoundError},
+		{"nothing.*.mtu", ErrNotFoundError},
+	}
+	for _, tt := range tests {
+		keys, err := ParsePath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Set(parseYaml(t, sriovYaml), keys, 9000); !errors.Is(err, tt.err) {
+			t.Errorf("Set %s: %v, want %v", tt.path, err, tt.err)
+		}
+	}
+}
+
+func TestSplitPattern(t *testing.T) {
+	tests := []struct {
+		path          string
+		pattern, rest string
+	}{
+		{"a.b", "", "a.b"},
+		{"a[*].b.c", "a[*]", "b.c"},
+		{"a.*", "a.*", ""},
+		{"..a.b", "..a", "b"},
+		{"x..a", "x..a", ""},
+		{"a[*]..b[0]", "a[*]..b", "[0]"},
+		{"a[?b==1][0]", "a[?b==1]", "[0]"},
+		{"a[1:2].b", "a[1:2]", "b"},
+	}
+	for _, tt := range tests {
+		keys, err := ParsePath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pattern, rest := splitPattern(keys)
+		if pattern.String() != tt.pattern || rest.String() != tt.rest {
+			t.Errorf("splitPattern(%s) = %s, %s, want %s, %s", tt.path,
+				pattern, rest, tt.pattern, tt.rest)
+		}
+	}
+}
+
+func TestMove(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     string
+		err      error
+	}{
+		// within one list, the index of to is the one after the subtraction
+		{"a[0]", "a[1]", "{a: [q, p, r], b: [1, 2]}", nil},
+		{"a[0]", "a[2]", "{a: [q, r, p], b: [1, 2]}", nil},
+		{"a[2]", "a[0]", "{a: [r, p, q], b: [1, 2]}", nil},
+		{"a[-1]", "a[0]", "{a: [r, p, q], b: [1, 2]}", nil},
+		{"a[0]", "a[-]", "{a: [q, r, p], b: [1, 2]}", nil},
+		// into another list
+		{"b[1]", "a[1]", "{a: [p, 2, q, r], b: [1]}", nil},
+		{"b[0]", "a[-]", "{a: [p, q, r, 1], b: [2]}", nil},
+		{"a[1]", "c[0]", "{a: [p, r], b: [1, 2], c: [q]}", nil},
+		{"a[1]", "c[-]", "{a: [p, r], b: [1, 2], c: [q]}", nil},
+		// into a map
+		{"b", "c.d", "{a: [p, q, r], c: {d: [1, 2]}}", nil},
+		{"a[0]", "b", "{a: [q, r], b: p}", nil},
+		{"a", "b[0]", "{b: [[p, q, r], 1, 2]}", nil},
+		// the other indexes of to are the ones before the subtraction
+		{"l[0]", "l[2].k", "{l: [{}, {k: {}}], a: [p, q, r], b: [1, 2]}", nil},
+		{"l[0]", "l[0].k", "", ErrInvalidIndexError},
+		{"a[3]", "b[0]", "", ErrIndexOutOfRangeError},
+		{"b[0]", "a[4]", "", ErrIndexOutOfRangeError},
+		{"a", "a[0]", "", ErrInvalidIndexError},
+	}
+	for _, tt := range tests {
+		data := parseYaml(t, "{a: [p, q, r], b: [1, 2]}")
+		if tt.from[0] == 'l' || tt.to[0] == 'l' {
+			data = parseYaml(t, "{l: [{}, {}, {}], a: [p, q, r], b: [1, 2]}")
+		}
+		before := yamlText(t, data)
+		from, err := ParsePath(tt.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		to, err := ParsePath(tt.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Move(data, from, to)
+		if after := yamlText(t, data); after != before {
+			t.Errorf("Move(%s, %s) modified the data:\n%s", tt.from, tt.to, after)
+		}
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Move(%s, %s): %v, want %v", tt.from, tt.to, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Move(%s, %s): %v", tt.from, tt.to, err)
+			continue
+		}
+		if want := yamlText(t, parseYaml(t, tt.want)); yamlText(t, got) != want {
+			t.Errorf("Move(%s, %s):\n%s\nwant:\n%s", tt.from, tt.to, yamlText(t, got), want)
+		}
+	}
+}
+
+func TestMoveNodeError(t *testing.T) {
+	var node yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(sriovYaml), &node); err != nil {
+		t.Fatal(err)
+	}
+	want := yamlText(t, nodeValue(&node))
+
+	// the sub node is subtracted from the copy, but cannot be set
+	from := Path{"sriov", "[0]", "ip"}
+	to := Path{"sriov", "[1]", "network", "ip"}
+	if _, err := Move(&node, from, to); err == nil {
+		t.Fatalf("Move(%s, %s): want error", from, to)
+	}
+	if got := yamlText(t, nodeValue(&node)); got != want {
+		t.Errorf("Move modified the node on error:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDeepCopyNode(t *testing.T) {
+	var node yamlv3.Node
+	src := "a: &x {b: 1}\nc: *x\n"
+	if err := yamlv3.Unmarshal([]byte(src), &node); err != nil {
+		t.Fatal(err)
+	}
+	ret := deepCopy(&node).(*yamlv3.Node)
+	if _, err := Subtract(ret, Path{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := yamlText(t, nodeValue(ret)); got != "a: {}\nc: {}\n" {
+		t.Errorf("the alias of the copy is not linked to its anchor:\n%s", got)
+	}
+	if got := yamlText(t, nodeValue(&node)); got != "a:\n  b: 1\nc:\n  b: 1\n" {
+		t.Errorf("the copy shares the nodes:\n%s", got)
+	}
+}
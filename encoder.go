@@ -0,0 +1,324 @@
+package yamlconv
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// An Encoder writes the JSON encoding of yaml structs to an output stream.
+//
+// The scalars are encoded as below:
+//   - string as JSON string, but a string of invalid UTF-8, e.g. decoded
+//     from !!binary, as JSON string of its standard base64 encoding, as
+//     yaml.v2 encodes it as !!binary. A !!binary of valid UTF-8 is decoded
+//     as a string by yaml.v2 and yaml.v3, and encoded as is.
+//   - []byte as JSON string of its standard base64 encoding
+//   - time.Time, e.g. decoded from !!timestamp of *yaml.Node by yaml.v3,
+//     as JSON string of RFC 3339. yaml.v2 decodes a timestamp as string,
+//     which is encoded as is.
+//   - int, int64, uint64 and the other integers as JSON number
+//   - float64 and float32 as JSON number, but the infinity and NaN as
+//     JSON string of ".inf", "-.inf" and ".nan"
+//   - bool as true or false, nil as null
+//   - the others as encoding/json does
+//
+// The map keys of any type are encoded as JSON string of its key name,
+// e.g. "1" for the integer key 1, and "null" for the nil key.
+type Encoder struct {
+	out        io.Writer
+	w          *bufio.Writer
+	prefix     string
+	indent     string
+	sortKeys   bool
+	escapeHTML bool
+	theme      *Theme
+}
+
+// NewEncoder returns a new encoder that writes to w, which sorts the keys
+// of golang maps and escapes HTML characters by default.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{out: w, w: bufio.NewWriter(w), sortKeys: true, escapeHTML: true}
+}
+
+// SetIndent instructs the encoder to format each subsequent encoded value
+// as if indented by json.Indent, i.e. each element begins on a new line
+// beginning with prefix followed by one or more copies of indent
+// according to the nesting depth.
+// Calling SetIndent("", "") disables indentation.
+func (e *Encoder) SetIndent(prefix, indent string) {
+	e.prefix = prefix
+	e.indent = indent
+}
+
+// SetSortKeys specifies whether the keys of golang maps are sorted by its
+// key name, or in the iteration order of the map.
+// The keys of yaml.MapSlice are always in its order.
+func (e *Encoder) SetSortKeys(on bool) {
+	e.sortKeys = on
+}
+
+// SetEscapeHTML specifies whether the problematic HTML characters <, >
+// and & are escaped inside JSON strings, as encoding/json does.
+func (e *Encoder) SetEscapeHTML(on bool) {
+	e.escapeHTML = on
+}
+
+// SetTheme specifies the theme to colour the output, or nil for no colour.
+func (e *Encoder) SetTheme(theme *Theme) {
+	e.theme = theme
+}
+
+// Encode writes the JSON encoding of the yaml struct data to the stream,
+// followed by a newline character.
+// The output is buffered, and a part of it may have been written if an
+// error is returned.
+func (e *Encoder) Encode(data interface{}) error {
+	if err := e.encode(data); err != nil {
+		return err
+	}
+	e.w.WriteByte('\n')
+	return e.w.Flush()
+}
+
+// encode writes the JSON encoding of data without a newline.
+func (e *Encoder) encode(data interface{}) error {
+	if err := e.value(data, 0); err != nil {
+		// drop the buffered partial output
+		e.w.Reset(e.out)
+		return err
+	}
+	return e.w.Flush()
+}
+
+// newline writes a newline and the indentation of depth, if indented.
+func (e *Encoder) newline(depth int) {
+	if len(e.prefix) == 0 && len(e.indent) == 0 {
+		return
+	}
+	e.w.WriteByte('\n')
+	e.w.WriteString(e.prefix)
+	for i := 0; i < depth; i++ {
+		e.w.WriteString(e.indent)
+	}
+}
+
+func (e *Encoder) value(data interface{}, depth int) error {
+	if color := e.theme.scalar(data); len(color) > 0 {
+		e.w.WriteString(color)
+		defer e.w.WriteString(colorReset)
+	}
+	switch m := data.(type) {
+	case []interface{}:
+		if len(m) == 0 {
+			e.w.WriteString("[]")
+			return nil
+		}
+		e.w.WriteByte('[')
+		for i, o := range m {
+			if i > 0 {
+				e.w.WriteByte(',')
+			}
+			e.newline(depth + 1)
+			if err := e.value(o, depth+1); err != nil {
+				return err
+			}
+		}
+		e.newline(depth)
+		e.w.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(m))
+		if e.sortKeys {
+			keys = sortedKeys(m)
+		} else {
+			for k := range m {
+				keys = append(keys, k)
+			}
+		}
+		items := make(yaml.MapSlice, len(keys))
+		for i, k := range keys {
+			items[i] = yaml.MapItem{Key: k, Value: m[k]}
+		}
+		return e.object(items, depth)
+	case map[interface{}]interface{}:
+		keys := make([]interface{}, 0, len(m))
+		if e.sortKeys {
+			keys = sortedKeys(m)
+		} else {
+			for k := range m {
+				keys = append(keys, k)
+			}
+		}
+		items := make(yaml.MapSlice, len(keys))
+		for i, k := range keys {
+			items[i] = yaml.MapItem{Key: k, Value: m[k]}
+		}
+		return e.object(items, depth)
+	case yaml.MapSlice:
+		return e.object(m, depth)
+	case *yamlv3.Node:
+		return e.value(nodeValue(m), depth)
+	case string:
+		if !utf8.ValidString(m) {
+			e.string(base64.StdEncoding.EncodeToString([]byte(m)))
+		} else {
+			e.string(m)
+		}
+	case []byte:
+		e.string(base64.StdEncoding.EncodeToString(m))
+	case bool:
+		e.w.WriteString(strconv.FormatBool(m))
+	case nil:
+		e.w.WriteString("null")
+	case int:
+		e.w.WriteString(strconv.Itoa(m))
+	case int64:
+		e.w.WriteString(strconv.FormatInt(m, 10))
+	case uint64:
+		e.w.WriteString(strconv.FormatUint(m, 10))
+	case float64:
+		e.float(m, 64)
+	case float32:
+		e.float(float64(m), 32)
+	case time.Time:
+		e.string(m.Format(time.RFC3339Nano))
+	default:
+		buf, err := json.Marshal(m)
+		if err != nil {
+			return &InvalidValueError{
+				fmt.Errorf("cannot encode %T: %s: %w", m, err.Error(),
+					ErrInvalidValueError)}
+		}
+		e.w.Write(buf)
+	}
+	return nil
+}
+
+// object writes the JSON object of the map items.
+func (e *Encoder) object(items yaml.MapSlice, depth int) error {
+	if len(items) == 0 {
+		e.w.WriteString("{}")
+		return nil
+	}
+	e.w.WriteByte('{')
+	for i, o := range items {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		e.newline(depth + 1)
+		colored := e.theme != nil && len(e.theme.Key) > 0
+		if colored {
+			e.w.WriteString(e.theme.Key)
+		}
+		if o.Key == nil {
+			e.string("null")
+		} else {
+			e.string(keyName(o.Key))
+		}
+		if colored {
+			e.w.WriteString(colorReset)
+		}
+		e.w.WriteByte(':')
+		if len(e.indent) > 0 || len(e.prefix) > 0 {
+			e.w.WriteByte(' ')
+		}
+		if err := e.value(o.Value, depth+1); err != nil {
+			return err
+		}
+	}
+	e.newline(depth)
+	e.w.WriteByte('}')
+	return nil
+}
+
+// float writes the JSON number of the float f of the bit size bits,
+// in the same format as encoding/json, or the JSON string of the yaml
+// notation of the infinity and NaN.
+func (e *Encoder) float(f float64, bits int) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		e.string(formatFloat(f, bits))
+		return
+	}
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b := strconv.AppendFloat(nil, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	e.w.Write(b)
+}
+
+// formatFloat returns the text of the float f of the bit size bits, in
+// the yaml notation of the infinity and NaN, i.e. .inf, -.inf and .nan.
+func formatFloat(f float64, bits int) string {
+	switch {
+	case math.IsInf(f, 1):
+		return ".inf"
+	case math.IsInf(f, -1):
+		return "-.inf"
+	case math.IsNaN(f):
+		return ".nan"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+// string writes the JSON string of s, as encoding/json does.
+func (e *Encoder) string(s string) {
+	const hex = "0123456789abcdef"
+	e.w.WriteByte('"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				e.w.WriteByte('\\')
+				e.w.WriteByte(c)
+			case c == '\n':
+				e.w.WriteString(`\n`)
+			case c == '\r':
+				e.w.WriteString(`\r`)
+			case c == '\t':
+				e.w.WriteString(`\t`)
+			case c < 0x20 || e.escapeHTML && (c == '<' || c == '>' || c == '&'):
+				e.w.WriteString(`\u00`)
+				e.w.WriteByte(hex[c>>4])
+				e.w.WriteByte(hex[c&0xf])
+			default:
+				e.w.WriteByte(c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			e.w.WriteString(`\ufffd`)
+		case r == '\u2028' || r == '\u2029':
+			e.w.WriteString(`\u202`)
+			e.w.WriteByte(hex[r&0xf])
+		default:
+			e.w.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	e.w.WriteByte('"')
+}
@@ -0,0 +1,82 @@
+package yamlconv
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const scalarYaml = `
+ts: 2001-12-14
+text: !!binary aGVsbG8=
+bin: !!binary /w==
+`
+
+func TestMarshalJsonScalar(t *testing.T) {
+	var data interface{}
+	if err := yaml.Unmarshal([]byte(scalarYaml), &data); err != nil {
+		t.Fatal(err)
+	}
+	var node yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(scalarYaml), &node); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		// yaml.v2 decodes a timestamp as string, and a binary as string
+		{"yaml.v2", data, `{"bin":"/w==","text":"hello","ts":"2001-12-14"}`},
+		// yaml.v3 decodes a timestamp as time.Time
+		{"yaml.v3", &node, `{"ts":"2001-12-14T00:00:00Z","text":"hello","bin":"/w=="}`},
+		{"time.Time", time.Date(2001, 12, 14, 21, 59, 43, 1e8, time.UTC), `"2001-12-14T21:59:43.1Z"`},
+		{"[]byte", []byte("hello"), `"aGVsbG8="`},
+	}
+	for _, tt := range tests {
+		buf, err := MarshalJson(tt.data, Path{})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if string(buf) != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, buf, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalJsonScalar(t *testing.T) {
+	type scalars struct {
+		Ts  time.Time
+		Bin []byte
+	}
+	var data interface{}
+	if err := yaml.Unmarshal([]byte(scalarYaml), &data); err != nil {
+		t.Fatal(err)
+	}
+	var node yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(scalarYaml), &node); err != nil {
+		t.Fatal(err)
+	}
+
+	// a timestamp of yaml.v2 is a string, which time.Time does not accept
+	var v scalars
+	if err := UnmarshalJson(data, Path{}, &v); err == nil {
+		t.Errorf("yaml.v2: time.Time accepts %v", v.Ts)
+	}
+	var s struct{ Ts string }
+	if err := UnmarshalJson(data, Path{}, &s); err != nil || s.Ts != "2001-12-14" {
+		t.Errorf("yaml.v2: %q, %v", s.Ts, err)
+	}
+
+	v = scalars{}
+	if err := UnmarshalJson(&node, Path{}, &v); err != nil {
+		t.Fatalf("yaml.v3: %v", err)
+	}
+	if !v.Ts.Equal(time.Date(2001, 12, 14, 0, 0, 0, 0, time.UTC)) || string(v.Bin) != "\xff" {
+		t.Errorf("yaml.v3: %v %q", v.Ts, v.Bin)
+	}
+}
@@ -0,0 +1,344 @@
+package yamlconv
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// predicate is a parsed filter expression of a '[?' expression ']' key,
+// which is evaluated against each list item.
+//
+// The filter expression is a form of below:
+// - operand, which is true if the operand exists and is not false or null
+// - operand op operand, where op is one of ==, !=, <, <=, >, >=, =~
+// - '!' expression, '(' expression ')'
+// - expression '&&' expression, expression '||' expression
+//
+// An operand is a quoted string, a number, true, false, null or
+// a path expression relative to the list item, e.g. "network" or
+// "ports[0].port". '@' is the list item itself.
+// A path which does not exist is not equal to any value, even null or
+// another path which does not exist.
+// The right operand of '=~' is a regular expression in a quoted string.
+type predicate interface {
+	match(data interface{}) bool
+}
+
+type orPredicate struct {
+	left, right predicate
+}
+
+func (p *orPredicate) match(data interface{}) bool {
+	return p.left.match(data) || p.right.match(data)
+}
+
+type andPredicate struct {
+	left, right predicate
+}
+
+func (p *andPredicate) match(data interface{}) bool {
+	return p.left.match(data) && p.right.match(data)
+}
+
+type notPredicate struct {
+	p predicate
+}
+
+func (p *notPredicate) match(data interface{}) bool {
+	return !p.p.match(data)
+}
+
+type comparePredicate struct {
+	op       string
+	lhs, rhs operand
+	re       *regexp.Regexp // compiled rhs of '=~'
+}
+
+func (p *comparePredicate) match(data interface{}) bool {
+	l, lok := p.lhs.eval(data)
+	if p.op == "" {
+		return lok && l != nil && l != false
+	}
+	r, rok := p.rhs.eval(data)
+
+	switch p.op {
+	case "==":
+		return lok && rok && equalScalar(l, r)
+	case "!=":
+		return !lok || !rok || !equalScalar(l, r)
+	case "=~":
+		s, ok := l.(string)
+		return ok && p.re.MatchString(s)
+	}
+
+	// ordering
+	var cmp int
+	if lf, ok := toFloat(l); ok {
+		rf, ok := toFloat(r)
+		if !ok {
+			return false
+		}
+		switch {
+		case lf < rf:
+			cmp = -1
+		case lf > rf:
+			cmp = 1
+		}
+	} else {
+		ls, lok := l.(string)
+		rs, rok := r.(string)
+		if !lok || !rok {
+			return false
+		}
+		cmp = strings.Compare(ls, rs)
+	}
+	switch p.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default: // ">="
+		return cmp >= 0
+	}
+}
+
+// operand is a literal value or a path relative to the list item.
+type operand struct {
+	literal interface{}
+	path    Path
+	isPath  bool
+}
+
+// eval returns the value of operand, and whether it exists.
+func (o operand) eval(data interface{}) (interface{}, bool) {
+	if !o.isPath {
+		return o.literal, true
+	}
+	if len(o.path) == 0 {
+		return data, true
+	}
+	v, err := Search(data, o.path)
+	if err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// equalScalar reports whether the scalar values a and b are equal,
+// comparing numbers by its value regardless of its type.
+func equalScalar(a, b interface{}) bool {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+	switch a.(type) {
+	case string, bool, nil:
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// toFloat returns the number n as float64, if n is a number.
+func toFloat(n interface{}) (float64, bool) {
+	switch v := n.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// parsePredicate parses the filter expression of a '[?' expression ']' key.
+func parsePredicate(expr string) (predicate, error) {
+	p := &predicateParser{expr: expr}
+	ret, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.expr) {
+		return nil, fmt.Errorf("unexpected %q at %d", p.expr[p.pos:], p.pos)
+	}
+	return ret, nil
+}
+
+type predicateParser struct {
+	expr string
+	pos  int
+}
+
+func (p *predicateParser) skipSpace() {
+	for p.pos < len(p.expr) && (p.expr[p.pos] == ' ' || p.expr[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// consume skips the token tok, if it is the next token.
+func (p *predicateParser) consume(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.expr[p.pos:], tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *predicateParser) parseOr() (predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orPredicate{left, right}
+	}
+	return left, nil
+}
+
+func (p *predicateParser) parseAnd() (predicate, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andPredicate{left, right}
+	}
+	return left, nil
+}
+
+func (p *predicateParser) parseUnary() (predicate, error) {
+	switch {
+	case p.consume("!") && !strings.HasPrefix(p.expr[p.pos:], "="):
+		sub, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notPredicate{sub}, nil
+	case p.consume("("):
+		sub, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, fmt.Errorf("expect ')' at %d", p.pos)
+		}
+		return sub, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *predicateParser) parseCompare() (predicate, error) {
+	lhs, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	cmp := &comparePredicate{lhs: lhs}
+	for _, op := range []string{"==", "!=", "=~", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			cmp.op = op
+			break
+		}
+	}
+	if cmp.op == "" {
+		return cmp, nil
+	}
+
+	at := p.pos
+	cmp.rhs, err = p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if cmp.op == "=~" {
+		s, ok := cmp.rhs.literal.(string)
+		if !ok || cmp.rhs.isPath {
+			return nil, fmt.Errorf("expect quoted regexp at %d", at)
+		}
+		cmp.re, err = regexp.Compile(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp at %d: %s", at, err.Error())
+		}
+	}
+	return cmp, nil
+}
+
+func (p *predicateParser) parseOperand() (operand, error) {
+	p.skipSpace()
+	if p.pos >= len(p.expr) {
+		return operand{}, fmt.Errorf("expect operand at %d", p.pos)
+	}
+
+	// quoted string
+	if c := p.expr[p.pos]; c == '"' || c == '\'' {
+		s, n, err := scanQuoted(p.expr[p.pos:])
+		if err != nil {
+			return operand{}, fmt.Errorf("%s at %d", err.Error(), p.pos)
+		}
+		p.pos += n
+		return operand{literal: s}, nil
+	}
+
+	// anything else up to the next operator
+	start := p.pos
+	depth := 0
+	for p.pos < len(p.expr) {
+		c := p.expr[p.pos]
+		if depth == 0 && strings.IndexByte(" \t=!<>&|()", c) >= 0 {
+			break
+		}
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '"', '\'':
+			_, n, err := scanQuoted(p.expr[p.pos:])
+			if err != nil {
+				return operand{}, fmt.Errorf("%s at %d", err.Error(), p.pos)
+			}
+			p.pos += n
+			continue
+		}
+		p.pos++
+	}
+	tok := p.expr[start:p.pos]
+	switch tok {
+	case "":
+		return operand{}, fmt.Errorf("expect operand at %d", start)
+	case "true":
+		return operand{literal: true}, nil
+	case "false":
+		return operand{literal: false}, nil
+	case "null":
+		return operand{literal: nil}, nil
+	case "@":
+		return operand{isPath: true}, nil
+	}
+	if i, err := strconv.Atoi(tok); err == nil {
+		return operand{literal: i}, nil
+	}
+	if f, err := strconv.ParseFloat(tok, 64); err == nil {
+		return operand{literal: f}, nil
+	}
+	path, err := ParsePath(strings.TrimPrefix(tok, "@"))
+	if err != nil {
+		return operand{}, fmt.Errorf("invalid operand %s at %d", tok, start)
+	}
+	return operand{path: path, isPath: true}, nil
+}
@@ -0,0 +1,122 @@
+package yamlconv
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestFilter(t *testing.T) {
+	items := []interface{}{
+		yaml.MapSlice{{Key: "name", Value: "a"}, {Key: "port", Value: 80}, {Key: "on", Value: true}},
+		yaml.MapSlice{{Key: "name", Value: "b"}, {Key: "port", Value: 8080}, {Key: "on", Value: false}},
+		yaml.MapSlice{{Key: "name", Value: "c"}, {Key: "port", Value: 443.0}, {Key: "tag", Value: nil}},
+		yaml.MapSlice{{Key: "name", Value: "10"}, {Key: "port", Value: "9"}},
+	}
+	tests := []struct {
+		expr string
+		want []int
+	}{
+		// existence and truthiness
+		{"name", []int{0, 1, 2, 3}},
+		{"on", []int{0}},
+		{"tag", nil},
+		{"!on", []int{1, 2, 3}},
+		{"!!on", []int{0}},
+		// numbers compare by value regardless of type
+		{"port==80", []int{0}},
+		{"port==80.0", []int{0}},
+		{"port==443", []int{2}},
+		{"port>100", []int{1, 2}},
+		{"port<=443", []int{0, 2}},
+		// strings compare as strings, not numbers
+		{"port=='9'", []int{3}},
+		{"port==9", nil},
+		{"port>'10'", []int{3}},
+		{"name<'b'", []int{0, 3}},
+		{"name>5", nil},
+		{"name!='a'", []int{1, 2, 3}},
+		// && binds tighter than ||
+		{"name=='a' || name=='b' && port==80", []int{0}},
+		{"(name=='a' || name=='b') && port==80", []int{0}},
+		{"name=='b' || name=='c' && port==80", []int{1}},
+		{"(name=='b' || name=='c') && port>100", []int{1, 2}},
+		{"!(name=='a') && port<1000", []int{2}},
+		{"!name=='a'", []int{1, 2, 3}},
+		// literals and the item itself
+		{"on==true", []int{0}},
+		{"on==false", []int{1}},
+		{"tag==null", []int{2}},
+		{"tag!=null", []int{0, 1, 3}},
+		{"missing==missing", nil},
+		{"missing!=missing", []int{0, 1, 2, 3}},
+		{"tag==missing", nil},
+		{"@.name=='c'", []int{2}},
+		{"name=~'^[ab]$'", []int{0, 1}},
+		{"name=~\"^1\"", []int{3}},
+	}
+	for _, tt := range tests {
+		key := "[?" + tt.expr + "]"
+		st, err := parseStep(key)
+		if err != nil {
+			t.Errorf("parseStep(%q): %v", key, err)
+			continue
+		}
+		var got []int
+		for i, item := range items {
+			if st.filter.match(item) {
+				got = append(got, i)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s matches %v, want %v", key, got, tt.want)
+		}
+	}
+}
+
+func TestFilterError(t *testing.T) {
+	tests := []string{
+		"",
+		"name==",
+		"==1",
+		"(name==1",
+		"name==1)",
+		"name=~x",
+		"name=~'('",
+		"name=='a",
+		"name==1 &&",
+		"name==1 || || port",
+	}
+	for _, expr := range tests {
+		if _, err := parsePredicate(expr); err == nil {
+			t.Errorf("parsePredicate(%q): want error", expr)
+		}
+	}
+}
+
+func TestFilterSearchAll(t *testing.T) {
+	var data interface{}
+	src := `
+sriov:
+- network: resource01
+  ip: 10.10.0.101
+- network: resource02
+  ip: 10.10.0.102
+`
+	if err := yaml.Unmarshal([]byte(src), &data); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ParsePath("sriov[?network=='resource02'].ip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches, err := SearchAll(data, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Path.String() != "sriov[1].ip" ||
+		matches[0].Value != "10.10.0.102" {
+		t.Errorf("SearchAll(%s) = %v", keys, matches)
+	}
+}
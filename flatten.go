@@ -0,0 +1,105 @@
+package yamlconv
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Leaf is a scalar of a yaml struct with its path, found by Flatten.
+type Leaf struct {
+	// Path is the concrete keys of the scalar.
+	Path Path
+	// Value is the scalar, or an empty map or list.
+	Value interface{}
+}
+
+// Flatten returns all the scalars of the yaml struct data with its path,
+// in the order described in Walk, e.g. sriov[0].ip = 10.10.0.101.
+// The empty maps and lists are returned as well, so that Unflatten
+// rebuilds them.
+//
+// It returns a Leaf of the empty Path, if data is a scalar.
+// The values of *yaml.Node of yaml.v3 are its yaml structs, i.e. the
+// decoded scalars, and the empty yaml.MapSlice and lists.
+func Flatten(data interface{}) []Leaf {
+	var leaves []Leaf
+	Walk(data, func(path Path, value interface{}) error {
+		if node, ok := value.(*yamlv3.Node); ok {
+			n := resolveNode(node)
+			if (n.Kind == yamlv3.MappingNode || n.Kind == yamlv3.SequenceNode) && len(n.Content) > 0 {
+				return nil
+			}
+			value = nodeValue(node)
+		}
+		switch m := value.(type) {
+		case []interface{}:
+			if len(m) > 0 {
+				return nil
+			}
+		case map[string]interface{}:
+			if len(m) > 0 {
+				return nil
+			}
+		case map[interface{}]interface{}:
+			if len(m) > 0 {
+				return nil
+			}
+		case yaml.MapSlice:
+			if len(m) > 0 {
+				return nil
+			}
+		}
+		leaves = append(leaves, Leaf{Path: path, Value: value})
+		return nil
+	})
+	return leaves
+}
+
+// Unflatten returns the yaml struct rebuilt from the leaves, as returned
+// by Flatten, where the maps are yaml.MapSlice of string keys in the
+// order of the leaves.
+//
+// The list items must be in the order of its index, where the missing
+// list items are null, e.g. of the leaves filtered by grep.
+// An error is returned if a path of the leaves is not concrete, or
+// conflicts with the others, e.g. a map key and an index of the same list.
+func Unflatten(leaves []Leaf) (interface{}, error) {
+	var data interface{}
+	for _, l := range leaves {
+		if !l.Path.IsConcrete() {
+			return nil, &InvalidPathError{
+				fmt.Errorf("expect concrete path, but %s: %w", l.Path,
+					ErrInvalidPathError)}
+		}
+		// pad the missing list items with null, e.g. of the grepped leaves
+		for i, key := range l.Path {
+			st, err := parseStep(key)
+			if err != nil || st.kind != indexStep || st.end || st.index < 0 {
+				continue
+			}
+			n := 0
+			if list, err := Search(data, l.Path[:i]); err == nil {
+				m, ok := list.([]interface{})
+				if !ok && list != nil {
+					continue
+				}
+				n = len(m)
+			}
+			for ; n < st.index; n++ {
+				data, err = Upsert(data, join(l.Path[:i], fmt.Sprintf("[%d]", n)), nil)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		var err error
+		data, err = Upsert(data, l.Path, l.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
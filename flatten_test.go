@@ -0,0 +1,128 @@
+package yamlconv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+func TestFlattenNode(t *testing.T) {
+	src := `
+sriov:
+- network: resource01
+  ip: 10.10.0.101
+empty: {}
+none: []
+ref: &x {a: 1}
+alias: *x
+`
+	var node yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(src), &node); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, l := range Flatten(&node) {
+		got = append(got, fmt.Sprintf("%s = %#v", l.Path, l.Value))
+	}
+	want := []string{
+		`sriov[0].network = "resource01"`,
+		`sriov[0].ip = "10.10.0.101"`,
+		`empty = yaml.MapSlice{}`,
+		`none = []interface {}{}`,
+		`ref.a = 1`,
+		`alias.a = 1`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Flatten:\n%q\nwant:\n%q", got, want)
+	}
+
+	// the same as the yaml struct of the node
+	data, err := Unflatten(Flatten(&node))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := Diff(nodeValue(&node), data); len(changes) != 0 {
+		t.Errorf("Unflatten: %v", changeText(changes))
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	tests := []struct {
+		name   string
+		leaves []Leaf
+		want   string
+	}{
+		{"the maps in the order of the leaves",
+			[]Leaf{{Path{"b", "c"}, 1}, {Path{"a"}, "x"}, {Path{"b", "d"}, 2}},
+			"{b: {c: 1, d: 2}, a: x}"},
+		{"the missing list items are null",
+			[]Leaf{{Path{"sriov", "[2]", "ip"}, 1}, {Path{"l", "[1]", "[1]"}, 2}},
+			"{sriov: [null, null, {ip: 1}], l: [null, [null, 2]]}"},
+		{"the empty maps and lists",
+			[]Leaf{{Path{"a"}, yaml.MapSlice{}}, {Path{"b"}, []interface{}{}}},
+			"{a: {}, b: []}"},
+		{"a scalar",
+			[]Leaf{{Path{}, "x"}},
+			"x"},
+		{"a list",
+			[]Leaf{{Path{"[0]"}, 1}, {Path{"[1]", "a"}, 2}},
+			"[1, {a: 2}]"},
+	}
+	for _, tt := range tests {
+		got, err := Unflatten(tt.leaves)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		want := parseJson(t, tt.want)
+		if tt.want[0] == '{' {
+			want = parseYaml(t, tt.want)
+		}
+		if yamlText(t, got) != yamlText(t, want) {
+			t.Errorf("%s:\n%s\nwant:\n%s", tt.name, yamlText(t, got), yamlText(t, want))
+		}
+	}
+}
+
+func TestUnflattenError(t *testing.T) {
+	tests := []struct {
+		name   string
+		leaves []Leaf
+		err    error
+	}{
+		{"not concrete",
+			[]Leaf{{Path{"sriov", "[*]"}, 1}}, ErrInvalidPathError},
+		{"a map key and an index of the same list",
+			[]Leaf{{Path{"a", "[0]"}, 1}, {Path{"a", "b"}, 2}}, ErrInvalidIndexError},
+		{"a key under a scalar",
+			[]Leaf{{Path{"a"}, 1}, {Path{"a", "b"}, 2}}, ErrSearchKeyTooLongError},
+	}
+	for _, tt := range tests {
+		if _, err := Unflatten(tt.leaves); !errors.Is(err, tt.err) {
+			t.Errorf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestFlattenRoundTrip(t *testing.T) {
+	tests := []string{
+		sriovYaml,
+		"{a: {}, b: [], c: [[1, 2], [], [{}]], d: null, e: {f: [x, {g: true}]}}",
+		`{"a.b": 1, "x y": {"[0]": 2}, "*": 3}`,
+	}
+	for _, src := range tests {
+		data := parseYaml(t, src)
+		got, err := Unflatten(Flatten(data))
+		if err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		if yamlText(t, got) != yamlText(t, data) {
+			t.Errorf("Unflatten(Flatten(%s)):\n%s", src, yamlText(t, got))
+		}
+	}
+}
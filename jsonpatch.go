@@ -0,0 +1,223 @@
+package yamlconv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ApplyJSONPatch returns the yaml struct data patched by the JSON Patch
+// of RFC 6902, which is a yaml struct of the list of the operations,
+// e.g. [{"op": "add", "path": "/sriov/-", "value": {...}}].
+//
+// The operations add, remove, replace, move, copy and test are applied
+// in order to a copy of data, where the paths are the JSON Pointers of
+// RFC 6901. The order of the keys of yaml.MapSlice is kept, and a new key
+// is appended.
+//
+// An error is returned if the patch is invalid, an operation fails or
+// a test fails. data is not modified in any case.
+func ApplyJSONPatch(data interface{}, patch interface{}) (interface{}, error) {
+	ops, ok := patch.([]interface{})
+	if !ok {
+		return nil, &InvalidPatchError{
+			fmt.Errorf("expect list of operations, but %T: %w", patch,
+				ErrInvalidPatchError)}
+	}
+
+	ret := deepCopy(data)
+	for i, op := range ops {
+		var err error
+		ret, err = applyPatchOp(ret, op)
+		if err != nil {
+			return nil, fmt.Errorf("patch[%d]: %w", i, err)
+		}
+	}
+	return ret, nil
+}
+
+// applyPatchOp returns the yaml struct data patched by the operation op.
+func applyPatchOp(data interface{}, op interface{}) (interface{}, error) {
+	if _, ok := mapItems(op); !ok {
+		return nil, &InvalidPatchError{
+			fmt.Errorf("expect operation map, but %T: %w", op,
+				ErrInvalidPatchError)}
+	}
+	field := func(name string) (string, error) {
+		v, ok := mapGet(op, name)
+		s, isString := v.(string)
+		if !ok || !isString {
+			return "", &InvalidPatchError{
+				fmt.Errorf("expect string %s, but %v: %w", name, v,
+					ErrInvalidPatchError)}
+		}
+		return s, nil
+	}
+
+	name, err := field("op")
+	if err != nil {
+		return nil, err
+	}
+	ptr, err := field("path")
+	if err != nil {
+		return nil, err
+	}
+	value, hasValue := mapGet(op, "value")
+	switch name {
+	case "add", "replace", "test":
+		if !hasValue {
+			return nil, &InvalidPatchError{
+				fmt.Errorf("%s %s: no value: %w", name, ptr,
+					ErrInvalidPatchError)}
+		}
+	}
+	var from string
+	switch name {
+	case "move", "copy":
+		from, err = field("from")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch name {
+	case "add":
+		return patchAdd(data, ptr, deepCopy(value))
+	case "remove":
+		path, err := pointerPath(data, ptr)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return nil, nil
+		}
+		return Subtract(data, path)
+	case "replace":
+		path, err := pointerPath(data, ptr)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := Search(data, path); err != nil {
+			return nil, err
+		}
+		return Set(data, path, deepCopy(value))
+	case "move", "copy":
+		if name == "move" && strings.HasPrefix(ptr+"/", from+"/") && ptr != from {
+			return nil, &InvalidPatchError{
+				fmt.Errorf("cannot move %s into itself %s: %w", from, ptr,
+					ErrInvalidPatchError)}
+		}
+		path, err := pointerPath(data, from)
+		if err != nil {
+			return nil, err
+		}
+		sub, err := Search(data, path)
+		if err != nil {
+			return nil, err
+		}
+		if name == "copy" {
+			return patchAdd(data, ptr, deepCopy(sub))
+		}
+		if len(path) == 0 {
+			return patchAdd(nil, ptr, sub)
+		}
+		data, err = Subtract(data, path)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(data, ptr, sub)
+	case "test":
+		path, err := pointerPath(data, ptr)
+		if err != nil {
+			return nil, err
+		}
+		sub, err := Search(data, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(sub, value) {
+			return nil, &TestFailedError{
+				fmt.Errorf("test %s: %v is not %v: %w", ptr, sub, value,
+					ErrTestFailedError)}
+		}
+		return data, nil
+	default:
+		return nil, &InvalidPatchError{
+			fmt.Errorf("unknown op %s: %w", name,
+				ErrInvalidPatchError)}
+	}
+}
+
+// patchAdd returns the yaml struct data, where value is added at the
+// JSON Pointer ptr. value is inserted into a list, or replaces the
+// value of the map key.
+func patchAdd(data interface{}, ptr string, value interface{}) (interface{}, error) {
+	path, err := pointerPath(data, ptr)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent := path[:len(path)-1]
+	sub, err := Search(data, parent)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := sub.([]interface{}); !ok {
+		return Set(data, path, value)
+	}
+	st, _ := parseStep(path[len(path)-1])
+	if st.end {
+		return Append(data, parent, value)
+	}
+	return Insert(data, parent, st.index, value)
+}
+
+// pointerPath returns the Path of the JSON Pointer ptr of RFC 6901 in
+// the yaml struct data, where the reference tokens of lists are the list
+// indexes, and the others are the map keys.
+func pointerPath(data interface{}, ptr string) (Path, error) {
+	if len(ptr) == 0 {
+		return Path{}, nil
+	}
+	if ptr[0] != '/' {
+		return nil, &InvalidPathError{
+			fmt.Errorf("expect '/' at 0: %s: %w", ptr,
+				ErrInvalidPathError)}
+	}
+
+	tokens := strings.Split(ptr[1:], "/")
+	path := make(Path, 0, len(tokens))
+	cur := data
+	for i, token := range tokens {
+		token = strings.ReplaceAll(token, "~1", "/")
+		token = strings.ReplaceAll(token, "~0", "~")
+
+		list, ok := cur.([]interface{})
+		if !ok {
+			path = append(path, mapKey(token))
+			cur, _ = mapGet(cur, token)
+			continue
+		}
+		if token == "-" && i == len(tokens)-1 {
+			path = append(path, "[-]")
+			break
+		}
+		idx, err := strconv.Atoi(token)
+		if err != nil || idx < 0 || (len(token) > 1 && token[0] == '0') ||
+			token[0] == '+' {
+			return nil, &InvalidIndexError{
+				fmt.Errorf("invalid index: %s in %s: %w", token, ptr,
+					ErrInvalidIndexError)}
+		}
+		path = append(path, fmt.Sprintf("[%d]", idx))
+		if idx < len(list) {
+			cur = list[idx]
+		} else {
+			cur = nil
+		}
+	}
+	return path, nil
+}
@@ -0,0 +1,205 @@
+package yamlconv
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// parseJson returns the yaml struct of the JSON text src.
+func parseJson(t *testing.T, src string) interface{} {
+	t.Helper()
+	var data interface{}
+	if err := yaml.Unmarshal([]byte(src), &data); err != nil {
+		t.Fatalf("%s: %v", src, err)
+	}
+	return data
+}
+
+// the examples of RFC 6902 Appendix A
+func TestApplyJSONPatchRFC(t *testing.T) {
+	tests := []struct {
+		name       string
+		doc, patch string
+		want       string
+		err        error
+	}{
+		{"A.1 adding an object member",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			`{"baz": "qux", "foo": "bar"}`, nil},
+		{"A.2 adding an array element",
+			`{"foo": ["bar", "baz"]}`,
+			`[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			`{"foo": ["bar", "qux", "baz"]}`, nil},
+		{"A.3 removing an object member",
+			`{"baz": "qux", "foo": "bar"}`,
+			`[{"op": "remove", "path": "/baz"}]`,
+			`{"foo": "bar"}`, nil},
+		{"A.4 removing an array element",
+			`{"foo": ["bar", "qux", "baz"]}`,
+			`[{"op": "remove", "path": "/foo/1"}]`,
+			`{"foo": ["bar", "baz"]}`, nil},
+		{"A.5 replacing a value",
+			`{"baz": "qux", "foo": "bar"}`,
+			`[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			`{"baz": "boo", "foo": "bar"}`, nil},
+		{"A.6 moving a value",
+			`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			`{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`, nil},
+		{"A.7 moving an array element",
+			`{"foo": ["all", "grass", "cows", "eat"]}`,
+			`[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			`{"foo": ["all", "cows", "eat", "grass"]}`, nil},
+		{"A.8 testing a value: success",
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			`[{"op": "test", "path": "/baz", "value": "qux"},
+			  {"op": "test", "path": "/foo/1", "value": 2}]`,
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`, nil},
+		{"A.9 testing a value: error",
+			`{"baz": "qux"}`,
+			`[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			"", ErrTestFailedError},
+		{"A.10 adding a nested member object",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			`{"foo": "bar", "child": {"grandchild": {}}}`, nil},
+		{"A.11 ignoring unrecognized elements",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			`{"foo": "bar", "baz": "qux"}`, nil},
+		{"A.12 adding to a nonexistent target",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			"", ErrNotFoundError},
+		// A.13 invalid JSON Patch document is rejected by the yaml parser
+		{"A.14 ~ escape ordering",
+			`{"/": 9, "~1": 10}`,
+			`[{"op": "test", "path": "/~01", "value": 10}]`,
+			`{"/": 9, "~1": 10}`, nil},
+		{"A.15 comparing strings and numbers",
+			`{"/": 9, "~1": 10}`,
+			`[{"op": "test", "path": "/~01", "value": "10"}]`,
+			"", ErrTestFailedError},
+		{"A.16 adding an array value",
+			`{"foo": ["bar"]}`,
+			`[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			`{"foo": ["bar", ["abc", "def"]]}`, nil},
+	}
+	for _, tt := range tests {
+		doc := parseJson(t, tt.doc)
+		before := yamlText(t, doc)
+		got, err := ApplyJSONPatch(doc, parseJson(t, tt.patch))
+		if after := yamlText(t, doc); after != before {
+			t.Errorf("%s: the document is modified:\n%s", tt.name, after)
+		}
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: %v, want %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if want := parseJson(t, tt.want); !equal(got, want) {
+			t.Errorf("%s:\n%s\nwant:\n%s", tt.name, yamlText(t, got), yamlText(t, want))
+		}
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		doc, patch string
+		want       string
+		err        error
+	}{
+		{"~0 and ~1 in a map key",
+			`{"a/b": {"m~n": 1}}`,
+			`[{"op": "replace", "path": "/a~1b/m~0n", "value": 2}]`,
+			`{"a/b": {"m~n": 2}}`, nil},
+		{"replace the whole document",
+			`{"foo": "bar"}`,
+			`[{"op": "replace", "path": "", "value": [1]}]`,
+			`[1]`, nil},
+		{"copy a subtree",
+			`{"a": {"b": [1]}}`,
+			`[{"op": "copy", "from": "/a", "path": "/c"},
+			  {"op": "add", "path": "/c/b/-", "value": 2}]`,
+			`{"a": {"b": [1]}, "c": {"b": [1, 2]}}`, nil},
+		{"test a subtree",
+			`{"a": {"b": [1, {"c": null}]}}`,
+			`[{"op": "test", "path": "/a", "value": {"b": [1, {"c": null}]}}]`,
+			`{"a": {"b": [1, {"c": null}]}}`, nil},
+		{"remove a missing key",
+			`{"foo": "bar"}`,
+			`[{"op": "remove", "path": "/baz"}]`,
+			"", ErrNotFoundError},
+		{"replace a missing key",
+			`{"foo": "bar"}`,
+			`[{"op": "replace", "path": "/baz", "value": 1}]`,
+			"", ErrNotFoundError},
+		{"add out of the array",
+			`{"foo": [1]}`,
+			`[{"op": "add", "path": "/foo/2", "value": 1}]`,
+			"", ErrIndexOutOfRangeError},
+		{"leading zero index",
+			`{"foo": [1, 2]}`,
+			`[{"op": "remove", "path": "/foo/01"}]`,
+			"", ErrInvalidIndexError},
+		{"pointer without '/'",
+			`{"foo": "bar"}`,
+			`[{"op": "remove", "path": "foo"}]`,
+			"", ErrInvalidPathError},
+		{"move into itself",
+			`{"a": {"b": 1}}`,
+			`[{"op": "move", "from": "/a", "path": "/a/c"}]`,
+			"", ErrInvalidPatchError},
+		{"unknown op",
+			`{"foo": "bar"}`,
+			`[{"op": "merge", "path": "/foo"}]`,
+			"", ErrInvalidPatchError},
+		{"no value",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz"}]`,
+			"", ErrInvalidPatchError},
+		{"no from",
+			`{"foo": "bar"}`,
+			`[{"op": "copy", "path": "/baz"}]`,
+			"", ErrInvalidPatchError},
+		{"not a list",
+			`{"foo": "bar"}`,
+			`{"op": "remove", "path": "/foo"}`,
+			"", ErrInvalidPatchError},
+		{"a failed op leaves no partial result",
+			`{"foo": "bar"}`,
+			`[{"op": "remove", "path": "/foo"},
+			  {"op": "test", "path": "/foo", "value": "bar"}]`,
+			"", ErrNotFoundError},
+	}
+	for _, tt := range tests {
+		doc := parseJson(t, tt.doc)
+		before := yamlText(t, doc)
+		got, err := ApplyJSONPatch(doc, parseJson(t, tt.patch))
+		if after := yamlText(t, doc); after != before {
+			t.Errorf("%s: the document is modified:\n%s", tt.name, after)
+		}
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: %v, want %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if want := parseJson(t, tt.want); !equal(got, want) {
+			t.Errorf("%s:\n%s\nwant:\n%s", tt.name, yamlText(t, got), yamlText(t, want))
+		}
+	}
+}
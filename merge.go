@@ -0,0 +1,105 @@
+package yamlconv
+
+// ListStrategy is the strategy of Merge to merge two lists.
+type ListStrategy int
+
+const (
+	// ListReplace replaces the base list with the overlay list.
+	ListReplace ListStrategy = iota
+	// ListAppend appends the overlay list items to the base list.
+	ListAppend
+	// ListMergeByKey merges the map items of the lists having the same
+	// value of the merge key, and appends the other overlay list items.
+	ListMergeByKey
+)
+
+// MergeOptions is the options of Merge.
+type MergeOptions struct {
+	// Lists is the strategy to merge the lists.
+	Lists ListStrategy
+	// MergeKey is the map key identifying the list items for
+	// ListMergeByKey, e.g. "name".
+	MergeKey string
+	// MergeKeys is the merge keys of the lists under the map keys,
+	// e.g. {"sriov": "network"}, which are merged by ListMergeByKey
+	// regardless of Lists.
+	MergeKeys map[string]string
+}
+
+// Merge returns the deep merge of the yaml struct overlay into the yaml
+// struct base. Neither base nor overlay is modified.
+//
+// The maps, i.e. yaml.MapSlice, map[interface{}]interface{} and
+// map[string]interface{}, are merged key by key into the map of the type
+// of base. The keys of yaml.MapSlice keep the order of base, followed by
+// the new keys of overlay.
+// The lists are merged by the strategy of opts, and any other value of
+// overlay replaces the one of base.
+func Merge(base, overlay interface{}, opts MergeOptions) interface{} {
+	return merge(deepCopy(base), overlay, "", &opts)
+}
+
+// merge returns the merge of overlay into base, where name is the map
+// key of base. base is modified in place.
+func merge(base, overlay interface{}, name string, opts *MergeOptions) interface{} {
+	if items, ok := mapItems(overlay); ok {
+		if _, ok := mapItems(base); !ok {
+			return deepCopy(overlay)
+		}
+		for _, o := range items {
+			v, ok := mapGet(base, o.Key)
+			if ok {
+				v = merge(v, o.Value, keyName(o.Key), opts)
+			} else {
+				v = deepCopy(o.Value)
+			}
+			base = mapPut(base, o.Key, v)
+		}
+		return base
+	}
+
+	ol, ok := overlay.([]interface{})
+	if !ok {
+		return deepCopy(overlay)
+	}
+	bl, ok := base.([]interface{})
+	if !ok {
+		return deepCopy(overlay)
+	}
+
+	strategy, key := opts.Lists, opts.MergeKey
+	if k, ok := opts.MergeKeys[name]; ok {
+		strategy, key = ListMergeByKey, k
+	}
+	switch strategy {
+	case ListAppend:
+		return append(bl, deepCopy(ol).([]interface{})...)
+	case ListMergeByKey:
+		for _, o := range ol {
+			i := indexByKey(bl, o, key)
+			if i < 0 {
+				bl = append(bl, deepCopy(o))
+			} else {
+				bl[i] = merge(bl[i], o, "", opts)
+			}
+		}
+		return bl
+	default:
+		return deepCopy(overlay)
+	}
+}
+
+// indexByKey returns the index of the map item of the list having the
+// same value of the map key as the map item, or -1 if there is none.
+func indexByKey(list []interface{}, item interface{}, key string) int {
+	want, ok := mapGet(item, key)
+	if !ok {
+		return -1
+	}
+	for i, o := range list {
+		if v, ok := mapGet(o, key); ok && equalScalar(v, want) {
+			return i
+		}
+	}
+	return -1
+}
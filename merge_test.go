@@ -0,0 +1,87 @@
+package yamlconv
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestMerge(t *testing.T) {
+	base := `{name: web, sriov: [{network: a, mtu: 1500}, {network: b}], tags: [x], spec: {replicas: 1, image: v1}}`
+	tests := []struct {
+		name    string
+		overlay string
+		opts    MergeOptions
+		want    string
+	}{
+		{"the maps are merged key by key in the order of base",
+			`{spec: {image: v2, port: 80}, env: prod}`,
+			MergeOptions{},
+			`{name: web, sriov: [{network: a, mtu: 1500}, {network: b}], tags: [x], spec: {replicas: 1, image: v2, port: 80}, env: prod}`},
+		{"ListReplace",
+			`{sriov: [{network: b, mtu: 9000}], tags: [y]}`,
+			MergeOptions{Lists: ListReplace},
+			`{name: web, sriov: [{network: b, mtu: 9000}], tags: [y], spec: {replicas: 1, image: v1}}`},
+		{"ListAppend",
+			`{sriov: [{network: b, mtu: 9000}], tags: [y]}`,
+			MergeOptions{Lists: ListAppend},
+			`{name: web, sriov: [{network: a, mtu: 1500}, {network: b}, {network: b, mtu: 9000}], tags: [x, y], spec: {replicas: 1, image: v1}}`},
+		{"ListMergeByKey",
+			`{sriov: [{network: b, mtu: 9000}, {network: c}], tags: [y]}`,
+			MergeOptions{Lists: ListMergeByKey, MergeKey: "network"},
+			`{name: web, sriov: [{network: a, mtu: 1500}, {network: b, mtu: 9000}, {network: c}], tags: [x, y], spec: {replicas: 1, image: v1}}`},
+		{"MergeKeys of a list regardless of Lists",
+			`{sriov: [{network: a, mtu: 9000}], tags: [y]}`,
+			MergeOptions{Lists: ListReplace, MergeKeys: map[string]string{"sriov": "network"}},
+			`{name: web, sriov: [{network: a, mtu: 9000}, {network: b}], tags: [y], spec: {replicas: 1, image: v1}}`},
+		{"a scalar replaces a map, and a map replaces a scalar",
+			`{spec: none, name: {first: web}}`,
+			MergeOptions{},
+			`{name: {first: web}, sriov: [{network: a, mtu: 1500}, {network: b}], tags: [x], spec: none}`},
+		{"a null replaces the value",
+			`{spec: null}`,
+			MergeOptions{},
+			`{name: web, sriov: [{network: a, mtu: 1500}, {network: b}], tags: [x], spec: null}`},
+	}
+	for _, tt := range tests {
+		data := parseYaml(t, base)
+		overlay := parseYaml(t, tt.overlay)
+		got := Merge(data, overlay, tt.opts)
+		if after := yamlText(t, data); after != yamlText(t, parseYaml(t, base)) {
+			t.Errorf("%s: the base is modified:\n%s", tt.name, after)
+		}
+		if want := yamlText(t, parseYaml(t, tt.want)); yamlText(t, got) != want {
+			t.Errorf("%s:\n%s\nwant:\n%s", tt.name, yamlText(t, got), want)
+		}
+	}
+}
+
+func TestMergeMapTypes(t *testing.T) {
+	var base map[interface{}]interface{}
+	if err := yaml.Unmarshal([]byte(`{a: 1, b: {c: 2}}`), &base); err != nil {
+		t.Fatal(err)
+	}
+	overlay := map[string]interface{}{"b": yaml.MapSlice{{Key: "d", Value: 3}}, "e": 4}
+
+	// the maps are merged into the map of the type of base
+	got := Merge(base, overlay, MergeOptions{})
+	m, ok := got.(map[interface{}]interface{})
+	if !ok {
+		t.Fatalf("Merge returns %T, want map[interface{}]interface{}", got)
+	}
+	if _, ok := m["b"].(map[interface{}]interface{}); !ok {
+		t.Errorf("Merge returns b of %T, want map[interface{}]interface{}", m["b"])
+	}
+	want := parseYaml(t, `{a: 1, b: {c: 2, d: 3}, e: 4}`)
+	if !equal(got, want) {
+		t.Errorf("Merge:\n%s\nwant:\n%s", yamlText(t, got), yamlText(t, want))
+	}
+
+	// a new map of overlay keeps its type
+	got = Merge(parseYaml(t, `{a: 1}`), overlay, MergeOptions{})
+	if v, _ := Search(got, Path{"b"}); v == nil {
+		t.Fatalf("Merge: no b in\n%s", yamlText(t, got))
+	} else if _, ok := v.(yaml.MapSlice); !ok {
+		t.Errorf("Merge returns b of %T, want yaml.MapSlice", v)
+	}
+}
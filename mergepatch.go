@@ -0,0 +1,74 @@
+package yamlconv
+
+// ApplyMergePatch returns the yaml struct data patched by the JSON Merge
+// Patch of RFC 7386, which is a yaml struct. Neither data nor patch is
+// modified.
+//
+// A map of patch is merged key by key into the map of data, where a null
+// value deletes the map key, and any other value of patch replaces the
+// one of data. The keys of yaml.MapSlice keep the order of data, followed
+// by the new keys of patch.
+func ApplyMergePatch(data, patch interface{}) interface{} {
+	return mergePatch(deepCopy(data), patch)
+}
+
+// mergePatch returns the merge of patch into data, which is modified
+// in place.
+func mergePatch(data, patch interface{}) interface{} {
+	items, ok := mapItems(patch)
+	if !ok {
+		return deepCopy(patch)
+	}
+	if _, ok := mapItems(data); !ok {
+		data = newMap(patch)
+	}
+	for _, o := range items {
+		if o.Value == nil {
+			data = mapDelete(data, o.Key)
+			continue
+		}
+		v, _ := mapGet(data, o.Key)
+		data = mapPut(data, o.Key, mergePatch(v, o.Value))
+	}
+	return data
+}
+
+// CreateMergePatch returns the JSON Merge Patch of RFC 7386, which
+// patches the yaml struct original into the yaml struct modified by
+// ApplyMergePatch. The map of the patch is the same type as the one of
+// original, and the keys of yaml.MapSlice are in the order of original,
+// followed by the new keys of modified.
+//
+// As a null value deletes the map key in the patch, a map key of null
+// value in modified is deleted by the patch. The lists are replaced as
+// a whole.
+func CreateMergePatch(original, modified interface{}) interface{} {
+	om, ok := mapItems(original)
+	if !ok {
+		return deepCopy(modified)
+	}
+	mm, ok := mapItems(modified)
+	if !ok {
+		return deepCopy(modified)
+	}
+
+	patch := newMap(original)
+	for _, o := range om {
+		v, ok := mapGet(modified, o.Key)
+		switch {
+		case !ok:
+			patch = mapPut(patch, o.Key, nil)
+		case equal(o.Value, v):
+		case v == nil:
+			patch = mapPut(patch, o.Key, nil)
+		default:
+			patch = mapPut(patch, o.Key, CreateMergePatch(o.Value, v))
+		}
+	}
+	for _, o := range mm {
+		if _, ok := mapGet(original, o.Key); !ok {
+			patch = mapPut(patch, o.Key, deepCopy(o.Value))
+		}
+	}
+	return patch
+}
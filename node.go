@@ -0,0 +1,181 @@
+package yamlconv
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// A *yaml.Node of gopkg.in/yaml.v3 is accepted as a yaml struct by
+// Search, SearchAll, Subtract, Print and MarshalJson, where the found sub
+// yaml structs are *yaml.Node of its source position, and the errors
+// are prefixed with the position, e.g. "line 3 column 5: ...".
+// The aliases are followed, and the merge keys '<<' are plain keys.
+
+// resolveNode returns the content of the document node or the anchor
+// of the alias node, or node itself.
+func resolveNode(node *yamlv3.Node) *yamlv3.Node {
+	for {
+		switch {
+		case node.Kind == yamlv3.DocumentNode && len(node.Content) > 0:
+			node = node.Content[0]
+		case node.Kind == yamlv3.AliasNode && node.Alias != nil:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+}
+
+// nodeKind returns the name of the node kind for the error messages.
+func nodeKind(node *yamlv3.Node) string {
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		return "mapping"
+	case yamlv3.SequenceNode:
+		return "sequence"
+	case yamlv3.ScalarNode:
+		return "scalar"
+	}
+	return "empty"
+}
+
+// nodeError returns err of the node prefixed with its source position,
+// keeping the type of err.
+func nodeError(err error, node *yamlv3.Node) error {
+	at := func(err error) error {
+		return fmt.Errorf("line %d column %d: %w", node.Line, node.Column, err)
+	}
+	switch e := err.(type) {
+	case *NotFoundError:
+		return &NotFoundError{at(e.Err)}
+	case *InvalidIndexError:
+		return &InvalidIndexError{at(e.Err)}
+	case *IndexOutOfRangeError:
+		return &IndexOutOfRangeError{at(e.Err)}
+	case *SearchKeyTooLongError:
+		return &SearchKeyTooLongError{at(e.Err)}
+	case *InvalidEditError:
+		return &InvalidEditError{at(e.Err)}
+	}
+	return at(err)
+}
+
+// nodeChild returns the sub node of the node which matches the first key
+// of keys, together with the key of its concrete Path, the index of the
+// sub node in the content of the resolved node, and the resolved node.
+// The first key must not be a wildcard.
+func nodeChild(node *yamlv3.Node, keys Path) (*yamlv3.Node, string, int, *yamlv3.Node, error) {
+	st, err := parseStep(keys[0])
+	if err != nil {
+		return nil, "", 0, nil, err
+	}
+	node = resolveNode(node)
+
+	switch node.Kind {
+	case yamlv3.SequenceNode:
+		if st.kind != indexStep {
+			return nil, "", 0, nil, nodeError(&InvalidIndexError{
+				fmt.Errorf("expect key[%s], but sequence: %w", st.name,
+					ErrInvalidIndexError)}, node)
+		}
+		idx := st.index
+		if st.end {
+			idx = len(node.Content)
+		}
+		idx, err = listIndex(idx, len(node.Content), "sequence")
+		if err != nil {
+			return nil, "", 0, nil, nodeError(err, node)
+		}
+		return node.Content[idx], fmt.Sprintf("[%d]", idx), idx, node, nil
+	case yamlv3.MappingNode:
+		if st.kind == indexStep {
+			return nil, "", 0, nil, nodeError(&InvalidIndexError{
+				fmt.Errorf("expect index %d, but mapping: %w", st.index,
+					ErrInvalidIndexError)}, node)
+		}
+		var mkeys []interface{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k := node.Content[i]
+			if k.Value == st.name {
+				return node.Content[i+1], mapKey(st.name), i, node, nil
+			}
+			mkeys = append(mkeys, k.Value)
+		}
+		return nil, "", 0, nil, nodeError(&NotFoundError{
+			fmt.Errorf("search %s not in %s: %w", st.name, mkeys,
+				ErrNotFoundError)}, node)
+	default:
+		return nil, "", 0, nil, nodeError(&SearchKeyTooLongError{
+			fmt.Errorf("key left: %s: %w", keys,
+				ErrSearchKeyTooLongError)}, node)
+	}
+}
+
+// subtractNode removes the sub node matching the concrete keys from the
+// node in place.
+func subtractNode(node *yamlv3.Node, keys Path) error {
+	sub, _, i, parent, err := nodeChild(node, keys)
+	if err != nil {
+		return err
+	}
+	if len(keys) > 1 {
+		_, err := Subtract(sub, keys[1:])
+		return err
+	}
+	if parent.Kind == yamlv3.MappingNode {
+		parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+	} else {
+		parent.Content = append(parent.Content[:i], parent.Content[i+1:]...)
+	}
+	return nil
+}
+
+// nodeChildren returns the direct sub nodes of the node with its concrete
+// Path, where path is the concrete keys of the node.
+func nodeChildren(node *yamlv3.Node, path Path) []Match {
+	var ret []Match
+	node = resolveNode(node)
+	switch node.Kind {
+	case yamlv3.SequenceNode:
+		for i, o := range node.Content {
+			ret = append(ret, Match{join(path, fmt.Sprintf("[%d]", i)), o})
+		}
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			ret = append(ret, Match{join(path, mapKey(node.Content[i].Value)), node.Content[i+1]})
+		}
+	}
+	return ret
+}
+
+// nodeValue returns the yaml struct of the node, where the mappings are
+// yaml.MapSlice in its order, and the scalars are decoded by yaml.v3.
+func nodeValue(node *yamlv3.Node) interface{} {
+	node = resolveNode(node)
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		items := make(yaml.MapSlice, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			items = append(items, yaml.MapItem{
+				Key:   nodeValue(node.Content[i]),
+				Value: nodeValue(node.Content[i+1]),
+			})
+		}
+		return items
+	case yamlv3.SequenceNode:
+		list := make([]interface{}, len(node.Content))
+		for i, o := range node.Content {
+			list[i] = nodeValue(o)
+		}
+		return list
+	case yamlv3.ScalarNode:
+		var v interface{}
+		if err := node.Decode(&v); err != nil {
+			return node.Value
+		}
+		return v
+	}
+	return nil
+}
@@ -0,0 +1,496 @@
+package yamlconv
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path is a sequence of keys addressing a sub yaml struct.
+// a key in Path must be a form of below:
+// - '[' Integer ']', e.g. '[0]', '[10]', etc, where a negative index
+// counts from the end of the list, e.g. '[-1]' is the last list item.
+// - '[-]', the end of the list, which addresses the position after the
+// last list item, e.g. to append a list item.
+// - '[' Start ':' End [ ':' Step ] ']', e.g. '[1:3]', '[::2]', which
+// matches the list items of the slice as in python.
+// - '[' Quoted String ']', e.g. '["app.kubernetes.io/name"]', which is
+// always used as golang map[] key.
+// - '*', which matches any map key.
+// - '[*]', which matches any list item.
+// - '..', which matches the sub yaml struct and all its descendants.
+// - '[?' Filter Expression ']', e.g. "[?network=='resource01']", which
+// matches the list items satisfying the filter expression.
+// - any string that can be used as golang map[] key.
+//
+// A []string of keys is also a Path, so Path{"sriov", "[0]", "ip"}
+// addresses the same sub yaml struct as ParsePath("sriov[0].ip").
+type Path []string
+
+// ParsePath parses a path expression, e.g. "sriov[0].ip", into a Path.
+//
+// Map keys are separated by '.', and list indexes are enclosed by '[' and
+// ']'. A map key containing '.' or '[' can be escaped with '\', e.g.
+// "a\.b", or quoted, e.g. `metadata.labels["app.kubernetes.io/name"]`.
+// A leading '.' is optional and the empty string is the empty Path.
+// A list index may be negative, e.g. "sriov[-1]", and a slice, e.g.
+// "sriov[1:3]" or "sriov[::2]", matches the list items in the range.
+// The wildcards '*' and '[*]' match any map key and any list item.
+// The recursive descent '..' followed by a key, e.g. "..image", matches
+// the key at any depth.
+// The filter '[?' expression ']' matches the list items for which the
+// expression is true, e.g. "sriov[?network=='resource01'].ip". The
+// expression compares the fields of the list item with ==, !=, <, <=, >,
+// >= and =~ (regular expression), combined with &&, || and !.
+func ParsePath(s string) (Path, error) {
+	keys := Path{}
+	i := 0
+	if strings.HasPrefix(s, ".") && !strings.HasPrefix(s, "..") {
+		i++
+	}
+	for i < len(s) {
+		switch {
+		case strings.HasPrefix(s[i:], ".."):
+			i += 2
+			if i >= len(s) || s[i] == '.' {
+				return nil, &InvalidPathError{
+					fmt.Errorf("empty key at %d: %s: %w", i, s,
+						ErrInvalidPathError)}
+			}
+			keys = append(keys, "..")
+		case s[i] == '[':
+			key, n, err := scanBracket(s[i:])
+			if err != nil {
+				return nil, &InvalidPathError{
+					fmt.Errorf("%s at %d: %s: %w", err.Error(), i, s,
+						ErrInvalidPathError)}
+			}
+			keys = append(keys, key)
+			i += n
+		case s[i] == '.' && len(keys) > 0:
+			i++
+			if i >= len(s) || s[i] == '.' || s[i] == '[' {
+				return nil, &InvalidPathError{
+					fmt.Errorf("empty key at %d: %s: %w", i, s,
+						ErrInvalidPathError)}
+			}
+			fallthrough
+		default:
+			name, n, err := scanName(s[i:])
+			if err != nil {
+				return nil, &InvalidPathError{
+					fmt.Errorf("%s at %d: %s: %w", err.Error(), i, s,
+						ErrInvalidPathError)}
+			}
+			if s[i:i+n] == "*" {
+				keys = append(keys, "*")
+			} else {
+				keys = append(keys, mapKey(name))
+			}
+			i += n
+		}
+	}
+	return keys, nil
+}
+
+// String returns the path expression of the keys, which ParsePath
+// parses back to the same Path.
+func (p Path) String() string {
+	var b strings.Builder
+	for i, key := range p {
+		switch {
+		case strings.HasPrefix(key, "["):
+			b.WriteString(key)
+		case key == "..":
+			b.WriteString(key)
+		case key == "*" || isPlainName(key):
+			if b.Len() > 0 && p[i-1] != ".." {
+				b.WriteByte('.')
+			}
+			b.WriteString(key)
+		default:
+			b.WriteString("[" + strconv.Quote(key) + "]")
+		}
+	}
+	return b.String()
+}
+
+// Pointer returns the JSON Pointer of RFC 6901 of the concrete keys,
+// e.g. "/sriov/0/ip" of "sriov[0].ip".
+func (p Path) Pointer() string {
+	var b strings.Builder
+	for _, key := range p {
+		st, err := parseStep(key)
+		b.WriteByte('/')
+		switch {
+		case err != nil:
+			b.WriteString(pointerEscape(key))
+		case st.end:
+			b.WriteByte('-')
+		case st.kind == indexStep:
+			b.WriteString(strconv.Itoa(st.index))
+		default:
+			b.WriteString(pointerEscape(st.name))
+		}
+	}
+	return b.String()
+}
+
+// pointerEscape returns the reference token of JSON Pointer of the map
+// key name.
+func pointerEscape(name string) string {
+	name = strings.ReplaceAll(name, "~", "~0")
+	return strings.ReplaceAll(name, "/", "~1")
+}
+
+// sortPaths sorts the concrete paths in the order of the yaml struct,
+// where the list indexes are compared by its number, and removes the
+// duplicated paths.
+func sortPaths(paths []Path) []Path {
+	sort.Slice(paths, func(i, j int) bool {
+		return comparePath(paths[i], paths[j]) < 0
+	})
+	ret := paths[:0]
+	for i, p := range paths {
+		if i == 0 || comparePath(p, paths[i-1]) != 0 {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+// subtractOrder returns the concrete paths of the matches in the order to
+// subtract them, i.e. the descendants and the later list items first, so
+// that the remaining paths are still valid.
+func subtractOrder(matches []Match) []Path {
+	paths := make([]Path, len(matches))
+	for i, m := range matches {
+		paths[i] = m.Path
+	}
+	paths = sortPaths(paths)
+	for i, j := 0, len(paths)-1; i < j; i, j = i+1, j-1 {
+		paths[i], paths[j] = paths[j], paths[i]
+	}
+	return paths
+}
+
+// comparePath compares the concrete paths a and b.
+func comparePath(a, b Path) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		sa, erra := parseStep(a[i])
+		sb, errb := parseStep(b[i])
+		if erra == nil && errb == nil &&
+			sa.kind == indexStep && sb.kind == indexStep {
+			return sa.index - sb.index
+		}
+		return strings.Compare(a[i], b[i])
+	}
+	return len(a) - len(b)
+}
+
+// listIndex returns the position of the list index idx in a list of
+// length n, where a negative idx counts from the end of the list.
+// kind is the type name of the list used in the error message.
+func listIndex(idx, n int, kind string) (int, error) {
+	pos := idx
+	if pos < 0 {
+		pos += n
+	}
+	if pos < 0 || pos >= n {
+		return 0, &IndexOutOfRangeError{
+			fmt.Errorf("index %d out of len(%s) %d: %w: %w", idx, kind, n,
+				ErrIndexOutOfRangeError, ErrNotFoundError)}
+	}
+	return pos, nil
+}
+
+// mapKey returns the key of Path which matches the map key name.
+func mapKey(name string) string {
+	if len(name) == 0 || name == "*" || name == ".." ||
+		strings.HasPrefix(name, "[") {
+		return "[" + strconv.Quote(name) + "]"
+	}
+	return name
+}
+
+// isPlainName reports whether the map key name can be written in
+// a path expression without quoting.
+func isPlainName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for _, r := range name {
+		switch r {
+		case '.', '[', ']', '"', '\'', '\\', ' ', '\t', '\n', '\r':
+			return false
+		}
+	}
+	return name != "*"
+}
+
+// IsConcrete reports whether the keys address at most one sub yaml
+// struct, i.e. the keys have no wildcard.
+func (p Path) IsConcrete() bool {
+	for _, key := range p {
+		st, err := parseStep(key)
+		if err == nil && st.kind != keyStep && st.kind != indexStep {
+			return false
+		}
+	}
+	return true
+}
+
+// splitPattern splits the keys into the pattern up to the last wildcard,
+// including the key following '..', and the rest of the concrete keys.
+func splitPattern(keys Path) (Path, Path) {
+	n := 0
+	for i, key := range keys {
+		st, err := parseStep(key)
+		if err == nil && st.kind != keyStep && st.kind != indexStep {
+			n = i + 1
+			if st.kind == descentStep && n < len(keys) {
+				n++
+			}
+		}
+	}
+	return keys[:n], keys[n:]
+}
+
+// scanName returns the unescaped map key at the head of s and
+// the number of bytes consumed.
+func scanName(s string) (string, int, error) {
+	var b strings.Builder
+	i := 0
+	for i < len(s) && s[i] != '.' && s[i] != '[' {
+		if s[i] == '\\' {
+			i++
+			if i >= len(s) {
+				return "", 0, fmt.Errorf("trailing escape")
+			}
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	if b.Len() == 0 {
+		return "", 0, fmt.Errorf("empty key")
+	}
+	return b.String(), i, nil
+}
+
+// scanBracket returns the key enclosed by '[' and ']' at the head of s
+// and the number of bytes consumed.
+func scanBracket(s string) (string, int, error) {
+	if len(s) > 1 && (s[1] == '"' || s[1] == '\'') {
+		name, n, err := scanQuoted(s[1:])
+		if err != nil {
+			return "", 0, err
+		}
+		n++
+		if n >= len(s) || s[n] != ']' {
+			return "", 0, fmt.Errorf("expect ']'")
+		}
+		return mapKey(name), n + 1, nil
+	}
+
+	// find the matching ']', skipping the quoted strings
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			_, n, err := scanQuoted(s[i:])
+			if err != nil {
+				return "", 0, err
+			}
+			i += n - 1
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			key := s[:i+1]
+			if _, err := parseStep(key); err != nil {
+				return "", 0, fmt.Errorf("invalid key %s: %s", key, err.Error())
+			}
+			return key, i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unclosed '['")
+}
+
+// scanQuoted returns the unquoted string at the head of s, which must
+// start with a double or single quote, and the number of bytes consumed.
+// A double quoted string follows the golang string literal syntax, and
+// a single quoted string allows only backslash escapes.
+func scanQuoted(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case quote:
+			if quote == '\'' {
+				return b.String(), i + 1, nil
+			}
+			name, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid quoted key %s", s[:i+1])
+			}
+			return name, i + 1, nil
+		case '\\':
+			if i+1 >= len(s) {
+				return "", 0, fmt.Errorf("trailing escape")
+			}
+			if quote == '"' {
+				b.WriteByte(s[i])
+			}
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return "", 0, fmt.Errorf("unclosed quote")
+}
+
+// stepKind is the kind of a parsed key in Path.
+type stepKind int
+
+const (
+	keyStep stepKind = iota
+	indexStep
+	anyKeyStep
+	anyIndexStep
+	descentStep
+	filterStep
+	sliceStep
+)
+
+// step is a parsed key in Path.
+type step struct {
+	kind   stepKind
+	name   string    // map key of keyStep
+	index  int       // list index of indexStep
+	filter predicate // filter expression of filterStep
+	slice  [3]*int   // start, end and step of sliceStep
+	end    bool      // '[-]', the index of the end of the list
+}
+
+// parseStep parses a key in Path.
+func parseStep(key string) (step, error) {
+	switch {
+	case key == "*":
+		return step{kind: anyKeyStep}, nil
+	case key == "[*]":
+		return step{kind: anyIndexStep}, nil
+	case key == "..":
+		return step{kind: descentStep}, nil
+	case key == "[-]":
+		return step{kind: indexStep, end: true}, nil
+	case !strings.HasPrefix(key, "["):
+		return step{kind: keyStep, name: key}, nil
+	}
+	if !strings.HasSuffix(key, "]") {
+		return step{}, &InvalidIndexError{
+			fmt.Errorf("invalid index: %s: %w", key,
+				ErrInvalidIndexError)}
+	}
+	inner := key[1 : len(key)-1]
+	if strings.HasPrefix(inner, "?") {
+		filter, err := parsePredicate(inner[1:])
+		if err != nil {
+			return step{}, &InvalidIndexError{
+				fmt.Errorf("invalid filter: %s: %s: %w", key, err.Error(),
+					ErrInvalidIndexError)}
+		}
+		return step{kind: filterStep, filter: filter}, nil
+	}
+	if len(inner) > 0 && (inner[0] == '"' || inner[0] == '\'') {
+		name, n, err := scanQuoted(inner)
+		if err != nil || n != len(inner) {
+			return step{}, &InvalidIndexError{
+				fmt.Errorf("invalid quoted key: %s: %w", key,
+					ErrInvalidIndexError)}
+		}
+		return step{kind: keyStep, name: name}, nil
+	}
+	if strings.Contains(inner, ":") {
+		return parseSlice(key, inner)
+	}
+	idx, err := strconv.Atoi(inner)
+	if err != nil {
+		return step{}, &InvalidIndexError{
+			fmt.Errorf("invalid index: %s: %w", key,
+				ErrInvalidIndexError)}
+	}
+	return step{kind: indexStep, index: idx}, nil
+}
+
+// parseSlice parses the slice key, e.g. '[1:3]' or '[::2]'.
+func parseSlice(key, inner string) (step, error) {
+	st := step{kind: sliceStep}
+	parts := strings.Split(inner, ":")
+	if len(parts) > 3 {
+		return step{}, &InvalidIndexError{
+			fmt.Errorf("invalid slice: %s: %w", key,
+				ErrInvalidIndexError)}
+	}
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || (i == 2 && n == 0) {
+			return step{}, &InvalidIndexError{
+				fmt.Errorf("invalid slice: %s: %w", key,
+					ErrInvalidIndexError)}
+		}
+		st.slice[i] = &n
+	}
+	return st, nil
+}
+
+// indexes returns the positions of the list items of length n,
+// which the slice of sliceStep matches.
+func (st step) indexes(n int) []int {
+	inc := 1
+	if st.slice[2] != nil {
+		inc = *st.slice[2]
+	}
+	// the bounds, clamped to the list as in python
+	bound := func(p *int, def int) int {
+		if p == nil {
+			return def
+		}
+		i := *p
+		if i < 0 {
+			i += n
+		}
+		lo, hi := 0, n
+		if inc < 0 {
+			lo, hi = -1, n-1
+		}
+		if i < lo {
+			return lo
+		}
+		if i > hi {
+			return hi
+		}
+		return i
+	}
+
+	var ret []int
+	if inc > 0 {
+		for i := bound(st.slice[0], 0); i < bound(st.slice[1], n); i += inc {
+			ret = append(ret, i)
+		}
+	} else {
+		for i := bound(st.slice[0], n-1); i > bound(st.slice[1], -1); i += inc {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,190 @@
+package yamlconv
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Path
+	}{
+		{"", Path{}},
+		{"sriov", Path{"sriov"}},
+		{".sriov", Path{"sriov"}},
+		{"sriov[0].ip", Path{"sriov", "[0]", "ip"}},
+		{"sriov[-1]", Path{"sriov", "[-1]"}},
+		{"sriov[-]", Path{"sriov", "[-]"}},
+		{"[0][1]", Path{"[0]", "[1]"}},
+		{`a\.b`, Path{"a.b"}},
+		{`a\[0]`, Path{"a[0]"}},
+		{`metadata.labels["app.kubernetes.io/name"]`, Path{"metadata", "labels", "app.kubernetes.io/name"}},
+		{`labels['app.kubernetes.io/name']`, Path{"labels", "app.kubernetes.io/name"}},
+		{`a["x\"y"]`, Path{"a", `x"y`}},
+		{`a["tab\t"]`, Path{"a", "tab\t"}},
+		{`a['it\'s']`, Path{"a", "it's"}},
+		{`a["*"]`, Path{"a", `["*"]`}},
+		{`a[""]`, Path{"a", `[""]`}},
+		{`a["[0]"]`, Path{"a", `["[0]"]`}},
+		{"*", Path{"*"}},
+		{"sriov[*].ip", Path{"sriov", "[*]", "ip"}},
+		{"..image", Path{"..", "image"}},
+		{"spec..image", Path{"spec", "..", "image"}},
+		{"a..[0]", Path{"a", "..", "[0]"}},
+		{"sriov[1:3]", Path{"sriov", "[1:3]"}},
+		{"sriov[::-1]", Path{"sriov", "[::-1]"}},
+		{"sriov[?network=='a]b'].ip", Path{"sriov", "[?network=='a]b']", "ip"}},
+		{"a[?b[0]==1]", Path{"a", "[?b[0]==1]"}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePath(tt.in)
+		if err != nil {
+			t.Errorf("ParsePath(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParsePathError(t *testing.T) {
+	tests := []string{
+		"a..",
+		"a.",
+		"a.[0]",
+		"a[0",
+		"a[x]",
+		"a[1.5]",
+		`a["b]`,
+		`a["b"x]`,
+		`a\`,
+		"a[::0]",
+		"a[1:2:3:4]",
+		"a[?]",
+		"a[?b==]",
+		"a[?(b==1]",
+	}
+	for _, in := range tests {
+		got, err := ParsePath(in)
+		if err == nil {
+			t.Errorf("ParsePath(%q) = %q, want error", in, got)
+			continue
+		}
+		var perr *InvalidPathError
+		if !errors.As(err, &perr) || !errors.Is(err, ErrInvalidPathError) {
+			t.Errorf("ParsePath(%q): %T %v, want InvalidPathError", in, err, err)
+		}
+	}
+}
+
+func TestPathString(t *testing.T) {
+	tests := []struct {
+		in   Path
+		want string
+	}{
+		{Path{}, ""},
+		{Path{"sriov", "[0]", "ip"}, "sriov[0].ip"},
+		{Path{"a.b"}, `["a.b"]`},
+		{Path{"a", "x y"}, `a["x y"]`},
+		{Path{"..", "image"}, "..image"},
+		{Path{"spec", "..", "image"}, "spec..image"},
+		{Path{"a", "*", "[*]"}, "a.*[*]"},
+		{Path{"a", `["*"]`}, `a["*"]`},
+	}
+	for _, tt := range tests {
+		got := tt.in.String()
+		if got != tt.want {
+			t.Errorf("%q.String() = %q, want %q", tt.in, got, tt.want)
+			continue
+		}
+		back, err := ParsePath(got)
+		if err != nil || !reflect.DeepEqual(back, tt.in) {
+			t.Errorf("ParsePath(%q) = %q, %v, want %q", got, back, err, tt.in)
+		}
+	}
+}
+
+func TestPathPointer(t *testing.T) {
+	tests := []struct {
+		in   Path
+		want string
+	}{
+		{Path{}, ""},
+		{Path{"sriov", "[0]", "ip"}, "/sriov/0/ip"},
+		{Path{"a", "[-]"}, "/a/-"},
+		{Path{"a/b", "m~n"}, "/a~1b/m~0n"},
+		{Path{`["*"]`}, "/*"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.Pointer(); got != tt.want {
+			t.Errorf("%q.Pointer() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSliceIndexes(t *testing.T) {
+	tests := []struct {
+		key  string
+		want []int
+	}{
+		{"[1:3]", []int{1, 2}},
+		{"[:]", []int{0, 1, 2, 3, 4}},
+		{"[::2]", []int{0, 2, 4}},
+		{"[-2:]", []int{3, 4}},
+		{"[:-2]", []int{0, 1, 2}},
+		{"[3:1]", nil},
+		{"[::-1]", []int{4, 3, 2, 1, 0}},
+		{"[::-2]", []int{4, 2, 0}},
+		{"[3:0:-1]", []int{3, 2, 1}},
+		{"[-1:-3:-1]", []int{4, 3}},
+		{"[10:]", nil},
+		{"[-10:2]", []int{0, 1}},
+		{"[10::-1]", []int{4, 3, 2, 1, 0}},
+		{"[1:3:-1]", nil},
+	}
+	for _, tt := range tests {
+		st, err := parseStep(tt.key)
+		if err != nil {
+			t.Errorf("parseStep(%q): %v", tt.key, err)
+			continue
+		}
+		if st.kind != sliceStep {
+			t.Errorf("parseStep(%q).kind = %v, want sliceStep", tt.key, st.kind)
+			continue
+		}
+		if got := st.indexes(5); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s.indexes(5) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	data := []interface{}{"a", "b", "c"}
+	tests := []struct {
+		key  string
+		want interface{}
+		err  error
+	}{
+		{"[0]", "a", nil},
+		{"[-1]", "c", nil},
+		{"[-3]", "a", nil},
+		{"[3]", nil, ErrIndexOutOfRangeError},
+		{"[-4]", nil, ErrIndexOutOfRangeError},
+		{"x", nil, ErrInvalidIndexError},
+	}
+	for _, tt := range tests {
+		got, err := Search(data, Path{tt.key})
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Search(%s): %v, want %v", tt.key, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Search(%s) = %v, %v, want %v", tt.key, got, err, tt.want)
+		}
+	}
+}
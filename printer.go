@@ -0,0 +1,168 @@
+package yamlconv
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Printer prints the yaml struct in the format of Print, a line per
+// sub yaml struct, e.g.
+//
+//	M[sriov]
+//	  A[0/2]
+//	    M[network] Str{resource01}
+//
+// where A[index/length] is a list item, K[key] is a key of golang maps
+// and M[key] is a key of yaml.MapSlice.
+// The keys of golang maps are sorted by its key name.
+type Printer struct {
+	// Writer is the output, or os.Stdout if nil.
+	Writer io.Writer
+	// Indent is used to spacing each nesting level.
+	Indent string
+	// MaxDepth is the maximum nesting level printed, where the deeper
+	// sub yaml structs are printed as "...". Zero means no limit.
+	MaxDepth int
+	// MaxItems is the maximum number of list items printed, where the
+	// rest are printed as "... N more". Zero means no limit.
+	MaxItems int
+	// FullPath prints the full path of each sub yaml struct, e.g.
+	// sriov[0].network, instead of its key or index.
+	FullPath bool
+	// Types annotates the scalars with its type, e.g. Str{resource01},
+	// instead of the plain values.
+	Types bool
+	// Theme colours the output, or nil for no colour.
+	Theme *Theme
+}
+
+// Print prints the yaml struct data.
+// It returns the error of writing to Writer, if any.
+func (p *Printer) Print(data interface{}) error {
+	out := p.Writer
+	if out == nil {
+		out = os.Stdout
+	}
+	theme := p.Theme
+	if theme == nil {
+		theme = &Theme{}
+	}
+	w := bufio.NewWriter(out)
+	p.print(w, theme, data, Path{}, "", 0)
+	return w.Flush()
+}
+
+// print prints data with the label of data, where path is the concrete
+// keys of data and depth is the nesting level of data.
+func (p *Printer) print(w *bufio.Writer, theme *Theme, data interface{}, path Path, label string, depth int) {
+	if node, ok := data.(*yamlv3.Node); ok {
+		data = nodeValue(node)
+	}
+	if len(label) > 0 {
+		w.WriteString(strings.Repeat(p.Indent, depth-1))
+		w.WriteString(label)
+		label = "-" // written
+	}
+
+	items, isMap := mapItems(data)
+	list, isList := data.([]interface{})
+	if !isMap && !isList {
+		if len(label) > 0 {
+			w.WriteByte(' ')
+		}
+		w.WriteString(theme.Paint(theme.scalar(data), p.scalar(data)))
+		w.WriteByte('\n')
+		return
+	}
+	if p.MaxDepth > 0 && depth >= p.MaxDepth && (len(items) > 0 || len(list) > 0) {
+		if len(label) > 0 {
+			w.WriteByte(' ')
+		}
+		w.WriteString("...\n")
+		return
+	}
+	if len(label) > 0 {
+		w.WriteByte('\n')
+	}
+
+	label = ""
+	for i, o := range list {
+		if p.MaxItems > 0 && i >= p.MaxItems {
+			w.WriteString(strings.Repeat(p.Indent, depth))
+			fmt.Fprintf(w, "... %d more\n", len(list)-i)
+			break
+		}
+		key := fmt.Sprintf("[%d]", i)
+		if p.FullPath {
+			label = join(path, key).String()
+		} else {
+			label = fmt.Sprintf("A[%d/%d]", i, len(list))
+		}
+		p.print(w, theme, o, join(path, key), theme.Paint(theme.Index, label), depth+1)
+	}
+	_, isSlice := data.(yaml.MapSlice)
+	for _, o := range items {
+		key := mapKey(keyName(o.Key))
+		switch {
+		case p.FullPath:
+			label = join(path, key).String()
+		case isSlice:
+			label = fmt.Sprintf("M[%s]", keyName(o.Key))
+		default:
+			label = fmt.Sprintf("K[%s]", keyName(o.Key))
+		}
+		p.print(w, theme, o.Value, join(path, key), theme.Paint(theme.Key, label), depth+1)
+	}
+}
+
+// scalar returns the text of the scalar data, in the format of the JSON
+// mapping described in Encoder, but the infinity and NaN in the yaml
+// notation, e.g. Float{.inf}.
+func (p *Printer) scalar(data interface{}) string {
+	var typ, text string
+	switch m := data.(type) {
+	case string:
+		if utf8.ValidString(m) {
+			typ, text = "Str", m
+		} else {
+			typ, text = "Binary", base64.StdEncoding.EncodeToString([]byte(m))
+		}
+	case []byte:
+		typ, text = "Binary", base64.StdEncoding.EncodeToString(m)
+	case bool:
+		typ, text = "Bool", strconv.FormatBool(m)
+	case int:
+		typ, text = "Int", strconv.Itoa(m)
+	case int64:
+		typ, text = "Int64", strconv.FormatInt(m, 10)
+	case uint64:
+		typ, text = "Uint64", strconv.FormatUint(m, 10)
+	case float64:
+		typ, text = "Float", formatFloat(m, 64)
+	case float32:
+		typ, text = "Float32", formatFloat(float64(m), 32)
+	case time.Time:
+		typ, text = "Time", m.Format(time.RFC3339Nano)
+	case nil:
+		if p.Types {
+			return "{}"
+		}
+		return "null"
+	default:
+		typ, text = fmt.Sprintf("%T", m), fmt.Sprint(m)
+	}
+	if !p.Types {
+		return text
+	}
+	return typ + "{" + text + "}"
+}
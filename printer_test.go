@@ -0,0 +1,106 @@
+package yamlconv
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+// captureStdout returns the standard out written by fn.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	fn()
+	w.Close()
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
+
+func TestPrint(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"{sriov: [{network: resource01}], port: 80}",
+			"\nM[sriov]\n  A[0/1]\n    M[network] Str{resource01}\nM[port] Int{80}"},
+		{"{}", ""},
+		{"[]", ""},
+		{"10.10.0.101", " Str{10.10.0.101}"},
+	}
+	for _, tt := range tests {
+		var data interface{}
+		if tt.src[0] == '{' {
+			data = parseYaml(t, tt.src)
+		} else {
+			data = parseJson(t, tt.src)
+		}
+		got := captureStdout(t, func() { Print(data, "  ") })
+		if got != tt.want {
+			t.Errorf("Print(%s) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	src := `{sriov: [{network: a, opts: {mtu: 1500}}, {network: b}, {network: c}], port: 80}`
+	tests := []struct {
+		name string
+		p    Printer
+		want string
+	}{
+		{"plain", Printer{Indent: "  "}, `M[sriov]
+  A[0/3]
+    M[network] a
+    M[opts]
+      M[mtu] 1500
+  A[1/3]
+    M[network] b
+  A[2/3]
+    M[network] c
+M[port] 80
+`},
+		{"MaxDepth", Printer{Indent: "  ", MaxDepth: 2}, `M[sriov]
+  A[0/3] ...
+  A[1/3] ...
+  A[2/3] ...
+M[port] 80
+`},
+		{"MaxItems", Printer{Indent: "  ", MaxItems: 1, Types: true}, `M[sriov]
+  A[0/3]
+    M[network] Str{a}
+    M[opts]
+      M[mtu] Int{1500}
+  ... 2 more
+M[port] Int{80}
+`},
+		{"FullPath", Printer{Indent: "  ", MaxDepth: 3, MaxItems: 2, FullPath: true}, `sriov
+  sriov[0]
+    sriov[0].network a
+    sriov[0].opts ...
+  sriov[1]
+    sriov[1].network b
+  ... 1 more
+port 80
+`},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		tt.p.Writer = &b
+		if err := tt.p.Print(parseYaml(t, src)); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != tt.want {
+			t.Errorf("%s:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package yamlconv
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Match is a sub yaml struct found by SearchAll.
+type Match struct {
+	// Path is the concrete keys of the sub yaml struct, without wildcard.
+	Path Path
+	// Value is the sub yaml struct.
+	Value interface{}
+}
+
+// SearchAll returns all the match sub-structs of yaml struct data,
+// in the order of the yaml struct.
+// keys are used to filter the match sub yaml structs.
+// a key in keys must be a form described in Path, where the wildcard
+// '*' matches any map key, '[*]' matches any list item, '..' matches
+// the sub yaml struct and all its descendants and '[?' expression ']'
+// matches the list items satisfying the filter expression, and
+// '[' start:end:step ']' matches the list items in the slice.
+//
+// It returns the same yaml struct, if the keys is empty.
+//
+// An error is returned if there are no match at all. The error is the
+// one of the first key which did not match, if any.
+func SearchAll(data interface{}, keys Path) ([]Match, error) {
+	s := &searcher{steps: make(map[string]step)}
+	for _, key := range keys {
+		st, err := parseStep(key)
+		if err != nil {
+			return nil, err
+		}
+		s.steps[key] = st
+	}
+
+	s.search(data, keys, Path{})
+	if len(s.matches) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, &NotFoundError{
+			fmt.Errorf("no match for %s: %w", keys,
+				ErrNotFoundError)}
+	}
+	return s.matches, nil
+}
+
+// searcher collects the sub yaml structs matching keys.
+type searcher struct {
+	steps   map[string]step // parsed keys
+	matches []Match
+	err     error // the first error
+}
+
+func (s *searcher) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// search collects the sub yaml structs of data matching keys,
+// where path is the concrete keys of data.
+func (s *searcher) search(data interface{}, keys Path, path Path) {
+	if len(keys) == 0 || len(keys[0]) == 0 {
+		s.matches = append(s.matches, Match{Path: path, Value: data})
+		return
+	}
+
+	st := s.steps[keys[0]]
+	switch st.kind {
+	case anyKeyStep:
+		switch m := data.(type) {
+		case map[string]interface{}:
+			for _, k := range sortedKeys(m) {
+				s.search(m[k], keys[1:], join(path, mapKey(k)))
+			}
+		case map[interface{}]interface{}:
+			for _, k := range sortedKeys(m) {
+				s.search(m[k], keys[1:], join(path, mapKey(keyName(k))))
+			}
+		case yaml.MapSlice:
+			for _, o := range m {
+				s.search(o.Value, keys[1:], join(path, mapKey(keyName(o.Key))))
+			}
+		case *yamlv3.Node:
+			if n := resolveNode(m); n.Kind != yamlv3.MappingNode {
+				s.fail(nodeError(&InvalidIndexError{
+					fmt.Errorf("expect key[*], but %s: %w", nodeKind(n),
+						ErrInvalidIndexError)}, n))
+				return
+			}
+			for _, c := range nodeChildren(m, path) {
+				s.search(c.Value, keys[1:], c.Path)
+			}
+		default:
+			s.fail(&InvalidIndexError{
+				fmt.Errorf("expect key[*], but %T: %w", data,
+					ErrInvalidIndexError)})
+		}
+	case anyIndexStep:
+		switch m := data.(type) {
+		case []interface{}:
+			for i, o := range m {
+				s.search(o, keys[1:], join(path, fmt.Sprintf("[%d]", i)))
+			}
+		case yaml.MapSlice:
+			for i, o := range m {
+				s.search(o.Value, keys[1:], join(path, fmt.Sprintf("[%d]", i)))
+			}
+		case *yamlv3.Node:
+			n := resolveNode(m)
+			if n.Kind != yamlv3.SequenceNode {
+				s.fail(nodeError(&InvalidIndexError{
+					fmt.Errorf("expect index[*], but %s: %w", nodeKind(n),
+						ErrInvalidIndexError)}, n))
+				return
+			}
+			for i, o := range n.Content {
+				s.search(o, keys[1:], join(path, fmt.Sprintf("[%d]", i)))
+			}
+		default:
+			s.fail(&InvalidIndexError{
+				fmt.Errorf("expect index[*], but %T: %w", data,
+					ErrInvalidIndexError)})
+		}
+	case sliceStep:
+		switch m := data.(type) {
+		case []interface{}:
+			for _, i := range st.indexes(len(m)) {
+				s.search(m[i], keys[1:], join(path, fmt.Sprintf("[%d]", i)))
+			}
+		case yaml.MapSlice:
+			for _, i := range st.indexes(len(m)) {
+				s.search(m[i].Value, keys[1:], join(path, fmt.Sprintf("[%d]", i)))
+			}
+		case *yamlv3.Node:
+			n := resolveNode(m)
+			if n.Kind != yamlv3.SequenceNode {
+				s.fail(nodeError(&InvalidIndexError{
+					fmt.Errorf("expect index%s, but %s: %w", keys[0], nodeKind(n),
+						ErrInvalidIndexError)}, n))
+				return
+			}
+			for _, i := range st.indexes(len(n.Content)) {
+				s.search(n.Content[i], keys[1:], join(path, fmt.Sprintf("[%d]", i)))
+			}
+		default:
+			s.fail(&InvalidIndexError{
+				fmt.Errorf("expect index%s, but %T: %w", keys[0], data,
+					ErrInvalidIndexError)})
+		}
+	case filterStep:
+		switch m := data.(type) {
+		case []interface{}:
+			for i, o := range m {
+				if st.filter.match(o) {
+					s.search(o, keys[1:], join(path, fmt.Sprintf("[%d]", i)))
+				}
+			}
+		case yaml.MapSlice:
+			for i, o := range m {
+				if st.filter.match(o.Value) {
+					s.search(o.Value, keys[1:], join(path, fmt.Sprintf("[%d]", i)))
+				}
+			}
+		case *yamlv3.Node:
+			n := resolveNode(m)
+			if n.Kind != yamlv3.SequenceNode {
+				s.fail(nodeError(&InvalidIndexError{
+					fmt.Errorf("expect index%s, but %s: %w", keys[0], nodeKind(n),
+						ErrInvalidIndexError)}, n))
+				return
+			}
+			for i, o := range n.Content {
+				if st.filter.match(nodeValue(o)) {
+					s.search(o, keys[1:], join(path, fmt.Sprintf("[%d]", i)))
+				}
+			}
+		default:
+			s.fail(&InvalidIndexError{
+				fmt.Errorf("expect index%s, but %T: %w", keys[0], data,
+					ErrInvalidIndexError)})
+		}
+	case descentStep:
+		s.descend(data, keys[1:], path)
+	default:
+		sub, key, err := child(data, keys)
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		s.search(sub, keys[1:], join(path, key))
+	}
+}
+
+// descend collects the sub yaml structs of data and all its descendants
+// matching keys, where path is the concrete keys of data.
+func (s *searcher) descend(data interface{}, keys Path, path Path) {
+	walk(data, path, func(path Path, value interface{}) error {
+		// a descendant not matching keys is not an error
+		err := s.err
+		s.search(value, keys, path)
+		s.err = err
+		return nil
+	}, false)
+}
+
+// children returns the direct sub yaml structs of data with its concrete
+// Path, where path is the concrete keys of data.
+func children(data interface{}, path Path) []Match {
+	var ret []Match
+	switch m := data.(type) {
+	case []interface{}:
+		for i, o := range m {
+			ret = append(ret, Match{join(path, fmt.Sprintf("[%d]", i)), o})
+		}
+	case map[string]interface{}:
+		for _, k := range sortedKeys(m) {
+			ret = append(ret, Match{join(path, mapKey(k)), m[k]})
+		}
+	case map[interface{}]interface{}:
+		for _, k := range sortedKeys(m) {
+			ret = append(ret, Match{join(path, mapKey(keyName(k))), m[k]})
+		}
+	case yaml.MapSlice:
+		for _, o := range m {
+			ret = append(ret, Match{join(path, mapKey(keyName(o.Key))), o.Value})
+		}
+	case *yamlv3.Node:
+		return nodeChildren(m, path)
+	}
+	return ret
+}
+
+// join returns a new Path of the path followed by the key.
+func join(path Path, key string) Path {
+	return append(path[:len(path):len(path)], key)
+}
+
+// sortedKeys returns the keys of map m sorted by its key name,
+// so that the maps are traversed in a stable order.
+func sortedKeys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keyName(keys[i]) < keyName(keys[j])
+	})
+	return keys
+}
@@ -0,0 +1,320 @@
+package yamlconv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultMergeKeys is the merge keys of the common kubernetes lists,
+// which are merged by the merge key in StrategicMerge.
+var DefaultMergeKeys = map[string]string{
+	"containers":          "name",
+	"initContainers":      "name",
+	"ephemeralContainers": "name",
+	"volumes":             "name",
+	"volumeMounts":        "mountPath",
+	"volumeDevices":       "devicePath",
+	"env":                 "name",
+	"imagePullSecrets":    "name",
+	"hostAliases":         "ip",
+	"conditions":          "type",
+}
+
+// StrategicMergeOptions is the options of StrategicMerge.
+type StrategicMergeOptions struct {
+	// MergeKeys is the merge keys of the lists under the map keys,
+	// e.g. {"containers": "name"}. The other lists are replaced.
+	// DefaultMergeKeys is used, if MergeKeys is nil.
+	MergeKeys map[string]string
+}
+
+// StrategicMerge returns the yaml struct data patched by the strategic
+// merge patch, as kubectl patches the kubernetes objects. Neither data
+// nor patch is modified.
+//
+// The maps are merged key by key, where a null value deletes the key,
+// and the lists under the map keys of the merge keys are merged by the
+// merge key, while the other lists are replaced.
+// The patch directives below are supported:
+// - '$patch: replace' in a map replaces the map, and '{$patch: replace}'
+// in a list replaces the list.
+// - '$patch: delete' in a map deletes the map, or the list item having
+// the same merge key.
+// - '$retainKeys: [...]' in a map deletes the map keys not in the list.
+// - '$setElementOrder/<key>: [...]' in a map orders the list under the
+// map key, by the merge keys or the values in the list.
+// - '$deleteFromPrimitiveList/<key>: [...]' in a map deletes the values
+// in the list from the list under the map key.
+//
+// An error is returned if a patch directive is invalid.
+func StrategicMerge(data, patch interface{}, opts StrategicMergeOptions) (interface{}, error) {
+	if opts.MergeKeys == nil {
+		opts.MergeKeys = DefaultMergeKeys
+	}
+	ret, _, err := strategicMerge(deepCopy(data), patch, &opts)
+	return ret, err
+}
+
+const (
+	directivePatch              = "$patch"
+	directiveRetainKeys         = "$retainKeys"
+	directiveSetElementOrder    = "$setElementOrder/"
+	directiveDeleteFromPrimList = "$deleteFromPrimitiveList/"
+)
+
+// strategicMerge returns the merge of patch into data, and whether
+// data is deleted by '$patch: delete'. data is modified in place.
+func strategicMerge(data, patch interface{}, opts *StrategicMergeOptions) (interface{}, bool, error) {
+	items, ok := mapItems(patch)
+	if !ok {
+		return deepCopy(patch), false, nil
+	}
+
+	directive, err := patchDirective(patch)
+	if err != nil {
+		return nil, false, err
+	}
+	switch directive {
+	case "delete":
+		return nil, true, nil
+	case "replace":
+		data = nil
+	}
+	if _, ok := mapItems(data); !ok {
+		data = newMap(patch)
+	}
+
+	for _, o := range items {
+		name := keyName(o.Key)
+		if strings.HasPrefix(name, "$") {
+			continue
+		}
+		if o.Value == nil {
+			data = mapDelete(data, o.Key)
+			continue
+		}
+
+		var v interface{}
+		deleted := false
+		orig, _ := mapGet(data, o.Key)
+		if list, ok := o.Value.([]interface{}); ok {
+			v, err = strategicMergeList(orig, list, name, opts)
+		} else {
+			v, deleted, err = strategicMerge(orig, o.Value, opts)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if deleted {
+			data = mapDelete(data, o.Key)
+		} else {
+			data = mapPut(data, o.Key, v)
+		}
+	}
+
+	// the directives applied to the merged map
+	for _, o := range items {
+		name := keyName(o.Key)
+		switch {
+		case strings.HasPrefix(name, directiveDeleteFromPrimList):
+			data, err = deleteFromPrimitiveList(data,
+				strings.TrimPrefix(name, directiveDeleteFromPrimList), o.Value)
+		case strings.HasPrefix(name, directiveSetElementOrder):
+			data, err = setElementOrder(data,
+				strings.TrimPrefix(name, directiveSetElementOrder), o.Value, opts)
+		case name == directiveRetainKeys:
+			data, err = retainKeys(data, o.Value)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	return data, false, nil
+}
+
+// strategicMergeList returns the merge of the patch list into the list
+// data under the map key name.
+func strategicMergeList(data interface{}, patch []interface{}, name string, opts *StrategicMergeOptions) (interface{}, error) {
+	// {$patch: replace} replaces the list
+	replace := false
+	items := make([]interface{}, 0, len(patch))
+	for _, o := range patch {
+		if kv, ok := mapItems(o); ok && len(kv) == 1 {
+			directive, err := patchDirective(o)
+			if err != nil {
+				return nil, err
+			}
+			if directive == "replace" {
+				replace = true
+				continue
+			}
+		}
+		items = append(items, o)
+	}
+
+	key, ok := opts.MergeKeys[name]
+	list, isList := data.([]interface{})
+	if replace || !ok || !isList {
+		list = []interface{}{}
+	}
+	if !ok {
+		// replaced, with the directives in the map items applied
+		for _, o := range items {
+			v, deleted, err := strategicMerge(nil, o, opts)
+			if err != nil {
+				return nil, err
+			}
+			if !deleted {
+				list = append(list, v)
+			}
+		}
+		return list, nil
+	}
+
+	for _, o := range items {
+		i := indexByKey(list, o, key)
+		if i < 0 {
+			if _, ok := mapItems(o); !ok && containsScalar(list, o) {
+				continue
+			}
+			v, deleted, err := strategicMerge(nil, o, opts)
+			if err != nil {
+				return nil, err
+			}
+			if !deleted {
+				list = append(list, v)
+			}
+			continue
+		}
+		v, deleted, err := strategicMerge(list[i], o, opts)
+		if err != nil {
+			return nil, err
+		}
+		if deleted {
+			list = append(list[:i], list[i+1:]...)
+		} else {
+			list[i] = v
+		}
+	}
+	return list, nil
+}
+
+// patchDirective returns the value of the '$patch' directive of the map
+// patch, or "merge" if there is none.
+func patchDirective(patch interface{}) (string, error) {
+	v, ok := mapGet(patch, directivePatch)
+	if !ok {
+		return "merge", nil
+	}
+	switch v {
+	case "merge", "replace", "delete":
+		return v.(string), nil
+	}
+	return "", &InvalidValueError{
+		fmt.Errorf("invalid %s: %v: %w", directivePatch, v,
+			ErrInvalidValueError)}
+}
+
+// retainKeys returns the map data without the map keys not in keys.
+func retainKeys(data interface{}, keys interface{}) (interface{}, error) {
+	list, ok := keys.([]interface{})
+	if !ok {
+		return nil, &InvalidValueError{
+			fmt.Errorf("expect list of %s, but %T: %w", directiveRetainKeys, keys,
+				ErrInvalidValueError)}
+	}
+	items, _ := mapItems(data)
+	for _, o := range items {
+		if !containsScalar(list, keyName(o.Key)) {
+			data = mapDelete(data, o.Key)
+		}
+	}
+	return data, nil
+}
+
+// deleteFromPrimitiveList returns the map data, where the values are
+// deleted from the list under the map key name.
+func deleteFromPrimitiveList(data interface{}, name string, values interface{}) (interface{}, error) {
+	dels, ok := values.([]interface{})
+	if !ok {
+		return nil, &InvalidValueError{
+			fmt.Errorf("expect list of %s%s, but %T: %w", directiveDeleteFromPrimList,
+				name, values, ErrInvalidValueError)}
+	}
+	list, ok := mapGet(data, name)
+	if !ok {
+		return data, nil
+	}
+	l, ok := list.([]interface{})
+	if !ok {
+		return data, nil
+	}
+	ret := make([]interface{}, 0, len(l))
+	for _, o := range l {
+		if !containsScalar(dels, o) {
+			ret = append(ret, o)
+		}
+	}
+	return mapPut(data, name, ret), nil
+}
+
+// setElementOrder returns the map data, where the list under the map key
+// name is ordered by the order list, which is the list of the maps of the
+// merge key or the list of the values. The list items not in the order
+// list follow in the original order.
+func setElementOrder(data interface{}, name string, order interface{}, opts *StrategicMergeOptions) (interface{}, error) {
+	orders, ok := order.([]interface{})
+	if !ok {
+		return nil, &InvalidValueError{
+			fmt.Errorf("expect list of %s%s, but %T: %w", directiveSetElementOrder,
+				name, order, ErrInvalidValueError)}
+	}
+	list, ok := mapGet(data, name)
+	if !ok {
+		return data, nil
+	}
+	l, ok := list.([]interface{})
+	if !ok {
+		return data, nil
+	}
+
+	key := opts.MergeKeys[name]
+	used := make([]bool, len(l))
+	ret := make([]interface{}, 0, len(l))
+	for _, o := range orders {
+		for i, item := range l {
+			if used[i] {
+				continue
+			}
+			var match bool
+			if _, isMap := mapItems(o); isMap {
+				want, _ := mapGet(o, key)
+				v, ok := mapGet(item, key)
+				match = ok && equalScalar(v, want)
+			} else {
+				match = equalScalar(item, o)
+			}
+			if match {
+				used[i] = true
+				ret = append(ret, item)
+				break
+			}
+		}
+	}
+	for i, item := range l {
+		if !used[i] {
+			ret = append(ret, item)
+		}
+	}
+	return mapPut(data, name, ret), nil
+}
+
+// containsScalar reports whether the list contains the scalar value v.
+func containsScalar(list []interface{}, v interface{}) bool {
+	for _, o := range list {
+		if equalScalar(o, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package yamlconv
+
+import (
+	"errors"
+	"testing"
+)
+
+// the examples of the kubernetes strategic merge patch
+func TestStrategicMerge(t *testing.T) {
+	tests := []struct {
+		name        string
+		data, patch string
+		want        string
+	}{
+		{"merge the containers by name",
+			`{spec: {containers: [{name: nginx, image: nginx:1.14}]}}`,
+			`{spec: {containers: [{name: redis, image: redis}]}}`,
+			`{spec: {containers: [{name: nginx, image: nginx:1.14}, {name: redis, image: redis}]}}`},
+		{"update the container of the same name",
+			`{spec: {containers: [{name: nginx, image: nginx:1.14, ports: [{containerPort: 80}]}]}}`,
+			`{spec: {containers: [{name: nginx, image: nginx:1.16}]}}`,
+			`{spec: {containers: [{name: nginx, image: nginx:1.16, ports: [{containerPort: 80}]}]}}`},
+		{"replace the list without a merge key",
+			`{spec: {tolerations: [{effect: NoSchedule, key: a}]}}`,
+			`{spec: {tolerations: [{effect: NoSchedule, key: b}]}}`,
+			`{spec: {tolerations: [{effect: NoSchedule, key: b}]}}`},
+		{"null deletes the key",
+			`{metadata: {labels: {app: web, tier: front}}}`,
+			`{metadata: {labels: {tier: null}}}`,
+			`{metadata: {labels: {app: web}}}`},
+		{"$patch: replace in a map",
+			`{metadata: {labels: {app: web, tier: front}}}`,
+			`{metadata: {labels: {$patch: replace, env: prod}}}`,
+			`{metadata: {labels: {env: prod}}}`},
+		{"$patch: delete in a map",
+			`{spec: {strategy: {type: Recreate}, replicas: 1}}`,
+			`{spec: {strategy: {$patch: delete}}}`,
+			`{spec: {replicas: 1}}`},
+		{"$patch: delete of the list item",
+			`{spec: {containers: [{name: nginx}, {name: redis}]}}`,
+			`{spec: {containers: [{name: nginx, $patch: delete}]}}`,
+			`{spec: {containers: [{name: redis}]}}`},
+		{"$patch: replace of the list",
+			`{spec: {containers: [{name: nginx}, {name: redis}]}}`,
+			`{spec: {containers: [{name: envoy}, {$patch: replace}]}}`,
+			`{spec: {containers: [{name: envoy}]}}`},
+		{"$retainKeys",
+			`{spec: {strategy: {type: RollingUpdate, rollingUpdate: {maxSurge: 1}}}}`,
+			`{spec: {strategy: {$retainKeys: [type], type: Recreate}}}`,
+			`{spec: {strategy: {type: Recreate}}}`},
+		{"$setElementOrder of the merge keys",
+			`{spec: {containers: [{name: a}, {name: b}]}}`,
+			`{spec: {$setElementOrder/containers: [{name: c}, {name: b}, {name: a}], containers: [{name: c}]}}`,
+			`{spec: {containers: [{name: c}, {name: b}, {name: a}]}}`},
+		{"$setElementOrder of the values",
+			`{metadata: {finalizers: [a, b, c]}}`,
+			`{metadata: {$setElementOrder/finalizers: [c, a]}}`,
+			`{metadata: {finalizers: [c, a, b]}}`},
+		{"$deleteFromPrimitiveList",
+			`{metadata: {finalizers: [a, b, c]}}`,
+			`{metadata: {$deleteFromPrimitiveList/finalizers: [b, x]}}`,
+			`{metadata: {finalizers: [a, c]}}`},
+		{"the scalars in the list of a merge key are not repeated",
+			`{volumes: [a, b]}`,
+			`{volumes: [b, c]}`,
+			`{volumes: [a, b, c]}`},
+		{"merge the env by name deep in the containers",
+			`{spec: {containers: [{name: app, env: [{name: A, value: "1"}, {name: B, value: "2"}]}]}}`,
+			`{spec: {containers: [{name: app, env: [{name: B, value: "3"}, {name: C, value: "4"}]}]}}`,
+			`{spec: {containers: [{name: app, env: [{name: A, value: "1"}, {name: B, value: "3"}, {name: C, value: "4"}]}]}}`},
+		{"a new list of a merge key",
+			`{spec: {}}`,
+			`{spec: {volumes: [{name: data, $patch: delete}, {name: cache}]}}`,
+			`{spec: {volumes: [{name: cache}]}}`},
+	}
+	for _, tt := range tests {
+		data := parseYaml(t, tt.data)
+		before := yamlText(t, data)
+		got, err := StrategicMerge(data, parseYaml(t, tt.patch), StrategicMergeOptions{})
+		if after := yamlText(t, data); after != before {
+			t.Errorf("%s: the data is modified:\n%s", tt.name, after)
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if want := yamlText(t, parseYaml(t, tt.want)); yamlText(t, got) != want {
+			t.Errorf("%s:\n%s\nwant:\n%s", tt.name, yamlText(t, got), want)
+		}
+	}
+}
+
+func TestStrategicMergeKeys(t *testing.T) {
+	data := parseYaml(t, `{ports: [{port: 80, name: http}], containers: [{name: a}]}`)
+	patch := parseYaml(t, `{ports: [{port: 80, name: web}, {port: 443}], containers: [{name: b}]}`)
+	got, err := StrategicMerge(data, patch, StrategicMergeOptions{
+		MergeKeys: map[string]string{"ports": "port"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the lists not in MergeKeys are replaced, even the ones of DefaultMergeKeys
+	want := yamlText(t, parseYaml(t, `{ports: [{port: 80, name: web}, {port: 443}], containers: [{name: b}]}`))
+	if yamlText(t, got) != want {
+		t.Errorf("StrategicMerge:\n%s\nwant:\n%s", yamlText(t, got), want)
+	}
+}
+
+func TestStrategicMergeError(t *testing.T) {
+	tests := []string{
+		`{spec: {$patch: remove}}`,
+		`{spec: {containers: [{$patch: 1}]}}`,
+		`{spec: {$retainKeys: type}}`,
+		`{spec: {$setElementOrder/containers: a}}`,
+		`{spec: {$deleteFromPrimitiveList/finalizers: a}}`,
+	}
+	for _, patch := range tests {
+		data := parseYaml(t, `{spec: {containers: [{name: a}], finalizers: [a]}}`)
+		if _, err := StrategicMerge(data, parseYaml(t, patch), StrategicMergeOptions{}); !errors.Is(err, ErrInvalidValueError) {
+			t.Errorf("StrategicMerge(%s): %v, want %v", patch, err, ErrInvalidValueError)
+		}
+	}
+}
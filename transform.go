@@ -0,0 +1,147 @@
+package yamlconv
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// TransformAction is the action of TransformFunc on a sub yaml struct.
+type TransformAction int
+
+const (
+	// TransformKeep keeps the sub yaml struct, and transforms its children.
+	TransformKeep TransformAction = iota
+	// TransformReplace replaces the sub yaml struct with the new value,
+	// whose children are not transformed.
+	TransformReplace
+	// TransformDelete deletes the sub yaml struct from its parent.
+	TransformDelete
+)
+
+// TransformFunc is the function called by Transform for each sub yaml
+// struct, where path is the concrete keys of the sub yaml struct.
+// It returns the new value for TransformReplace, which is ignored for
+// the other actions.
+//
+// If the function returns SkipChildren, the sub yaml struct is kept
+// without transforming its children. If it returns Stop, the rest of
+// the yaml struct is kept as it is. If it returns any other error,
+// Transform stops and returns the error.
+type TransformFunc func(path Path, value interface{}) (interface{}, TransformAction, error)
+
+// Transform returns a new yaml struct of data, where data and all its
+// descendants are replaced, deleted or kept by fn in pre-order, in the
+// order described in Walk. data is not modified.
+//
+// It returns nil, if data itself is deleted.
+func Transform(data interface{}, fn TransformFunc) (interface{}, error) {
+	return TransformInPlace(deepCopy(data), fn)
+}
+
+// TransformInPlace is the same as Transform, but modifies the maps and
+// lists of data in place. data may be partially modified, if
+// an error is returned.
+func TransformInPlace(data interface{}, fn TransformFunc) (interface{}, error) {
+	ret, deleted, err := transform(data, Path{}, fn)
+	if err != nil && err != Stop {
+		return nil, err
+	}
+	if deleted {
+		return nil, nil
+	}
+	return ret, nil
+}
+
+// transform returns the transformed yaml struct of data, and whether it
+// is deleted, where path is the concrete keys of data.
+// On Stop, it returns the yaml struct transformed so far with Stop.
+func transform(data interface{}, path Path, fn TransformFunc) (interface{}, bool, error) {
+	value, action, err := fn(path, data)
+	if err == SkipChildren {
+		return data, false, nil
+	}
+	if err == Stop {
+		return data, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	switch action {
+	case TransformKeep:
+	case TransformReplace:
+		return value, false, nil
+	case TransformDelete:
+		return nil, true, nil
+	default:
+		return nil, false, &InvalidValueError{
+			fmt.Errorf("unknown transform action %d at %s: %w", action, path,
+				ErrInvalidValueError)}
+	}
+
+	switch m := data.(type) {
+	case []interface{}:
+		ret := m[:0]
+		for i, o := range m {
+			v, deleted, err := transform(o, join(path, fmt.Sprintf("[%d]", i)), fn)
+			if err != nil && err != Stop {
+				return nil, false, err
+			}
+			if !deleted {
+				ret = append(ret, v)
+			}
+			if err == Stop {
+				return append(ret, m[i+1:]...), false, Stop
+			}
+		}
+		return ret, false, nil
+	case map[string]interface{}:
+		for _, k := range sortedKeys(m) {
+			v, deleted, err := transform(m[k], join(path, mapKey(k)), fn)
+			if err != nil && err != Stop {
+				return nil, false, err
+			}
+			if deleted {
+				delete(m, k)
+			} else {
+				m[k] = v
+			}
+			if err == Stop {
+				return m, false, Stop
+			}
+		}
+		return m, false, nil
+	case map[interface{}]interface{}:
+		for _, k := range sortedKeys(m) {
+			v, deleted, err := transform(m[k], join(path, mapKey(keyName(k))), fn)
+			if err != nil && err != Stop {
+				return nil, false, err
+			}
+			if deleted {
+				delete(m, k)
+			} else {
+				m[k] = v
+			}
+			if err == Stop {
+				return m, false, Stop
+			}
+		}
+		return m, false, nil
+	case yaml.MapSlice:
+		ret := m[:0]
+		for i, o := range m {
+			v, deleted, err := transform(o.Value, join(path, mapKey(keyName(o.Key))), fn)
+			if err != nil && err != Stop {
+				return nil, false, err
+			}
+			if !deleted {
+				ret = append(ret, yaml.MapItem{Key: o.Key, Value: v})
+			}
+			if err == Stop {
+				return append(ret, m[i+1:]...), false, Stop
+			}
+		}
+		return ret, false, nil
+	}
+	return data, false, nil
+}
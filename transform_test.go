@@ -0,0 +1,146 @@
+package yamlconv
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	src := `{sriov: [{network: a, ip: 1}, {network: b, ip: 2}], password: x, port: 80}`
+	tests := []struct {
+		name string
+		fn   TransformFunc
+		want string
+	}{
+		{"keep",
+			func(Path, interface{}) (interface{}, TransformAction, error) {
+				return nil, TransformKeep, nil
+			},
+			src},
+		{"replace the scalars",
+			func(path Path, value interface{}) (interface{}, TransformAction, error) {
+				if v, ok := value.(int); ok {
+					return v * 10, TransformReplace, nil
+				}
+				return nil, TransformKeep, nil
+			},
+			`{sriov: [{network: a, ip: 10}, {network: b, ip: 20}], password: x, port: 800}`},
+		{"replace without the children transformed",
+			func(path Path, value interface{}) (interface{}, TransformAction, error) {
+				switch path.String() {
+				case "sriov":
+					return []interface{}{1}, TransformReplace, nil
+				case "sriov[0]":
+					return nil, TransformDelete, nil
+				}
+				return nil, TransformKeep, nil
+			},
+			`{sriov: [1], password: x, port: 80}`},
+		{"delete the map keys and list items",
+			func(path Path, value interface{}) (interface{}, TransformAction, error) {
+				switch path.String() {
+				case "password", "sriov[0]", "sriov[1].ip":
+					return nil, TransformDelete, nil
+				}
+				return nil, TransformKeep, nil
+			},
+			`{sriov: [{network: b}], port: 80}`},
+		{"SkipChildren",
+			func(path Path, value interface{}) (interface{}, TransformAction, error) {
+				switch len(path) {
+				case 0:
+					return nil, TransformKeep, nil
+				case 1:
+					if path[0] == "sriov" {
+						return nil, TransformDelete, SkipChildren
+					}
+				}
+				return nil, TransformDelete, nil
+			},
+			`{sriov: [{network: a, ip: 1}, {network: b, ip: 2}]}`},
+		{"Stop keeps the rest",
+			func(path Path, value interface{}) (interface{}, TransformAction, error) {
+				switch path.String() {
+				case "sriov[0].ip":
+					return nil, TransformDelete, nil
+				case "sriov[1]":
+					return nil, TransformDelete, Stop
+				}
+				return nil, TransformKeep, nil
+			},
+			`{sriov: [{network: a}, {network: b, ip: 2}], password: x, port: 80}`},
+	}
+	for _, tt := range tests {
+		data := parseYaml(t, src)
+		got, err := Transform(data, tt.fn)
+		if after := yamlText(t, data); after != yamlText(t, parseYaml(t, src)) {
+			t.Errorf("%s: the data is modified:\n%s", tt.name, after)
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if want := yamlText(t, parseYaml(t, tt.want)); yamlText(t, got) != want {
+			t.Errorf("%s:\n%s\nwant:\n%s", tt.name, yamlText(t, got), want)
+		}
+	}
+}
+
+func TestTransformDeleteRoot(t *testing.T) {
+	got, err := Transform(parseYaml(t, "{a: 1}"), func(Path, interface{}) (interface{}, TransformAction, error) {
+		return nil, TransformDelete, nil
+	})
+	if err != nil || got != nil {
+		t.Errorf("Transform = %v, %v, want nil", got, err)
+	}
+}
+
+func TestTransformError(t *testing.T) {
+	errFound := errors.New("found")
+	tests := []struct {
+		name string
+		fn   TransformFunc
+		err  error
+	}{
+		{"the error of fn",
+			func(path Path, value interface{}) (interface{}, TransformAction, error) {
+				if value == 1 {
+					return nil, TransformKeep, errFound
+				}
+				return nil, TransformKeep, nil
+			},
+			errFound},
+		{"unknown action",
+			func(Path, interface{}) (interface{}, TransformAction, error) {
+				return nil, TransformAction(9), nil
+			},
+			ErrInvalidValueError},
+	}
+	for _, tt := range tests {
+		data := parseYaml(t, "{a: [1, 2], b: 3}")
+		got, err := Transform(data, tt.fn)
+		if !errors.Is(err, tt.err) || got != nil {
+			t.Errorf("%s: %v, %v, want %v", tt.name, got, err, tt.err)
+		}
+		if after := yamlText(t, data); after != "a:\n- 1\n- 2\nb: 3\n" {
+			t.Errorf("%s: the data is modified:\n%s", tt.name, after)
+		}
+	}
+}
+
+func TestTransformInPlace(t *testing.T) {
+	data := []interface{}{1, 2, 3}
+	got, err := TransformInPlace(data, func(path Path, value interface{}) (interface{}, TransformAction, error) {
+		if value == 2 {
+			return nil, TransformDelete, nil
+		}
+		return nil, TransformKeep, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the list shares the array of data
+	if yamlText(t, got) != "- 1\n- 3\n" || &got.([]interface{})[0] != &data[0] {
+		t.Errorf("TransformInPlace = %v of %v", got, data)
+	}
+}
@@ -0,0 +1,70 @@
+package yamlconv
+
+import "errors"
+
+// SkipChildren is used as a return value from WalkFunc to indicate that
+// the children of the sub yaml struct are to be skipped.
+// It is ignored by WalkPostOrder, where the children are already walked.
+var SkipChildren = errors.New("skip children")
+
+// Stop is used as a return value from WalkFunc to indicate that
+// the rest of the yaml struct is to be skipped.
+var Stop = errors.New("stop walking")
+
+// WalkFunc is the function called by Walk for each sub yaml struct,
+// where path is the concrete keys of the sub yaml struct.
+//
+// If the function returns an error other than SkipChildren or Stop,
+// Walk stops and returns the error.
+type WalkFunc func(path Path, value interface{}) error
+
+// Walk walks the yaml struct data in pre-order, calling fn for data and
+// all its descendants in the order of the yaml struct, i.e. a sub yaml
+// struct before its children.
+// The maps are walked in the order of yaml.MapSlice, or sorted by its
+// key name for golang maps, and the lists in the order of the list.
+//
+// It returns nil if fn returns Stop, or the error returned by fn.
+func Walk(data interface{}, fn WalkFunc) error {
+	err := walk(data, Path{}, fn, false)
+	if err == Stop {
+		return nil
+	}
+	return err
+}
+
+// WalkPostOrder walks the yaml struct data as Walk does, but in
+// post-order, i.e. a sub yaml struct after its children.
+func WalkPostOrder(data interface{}, fn WalkFunc) error {
+	err := walk(data, Path{}, fn, true)
+	if err == Stop {
+		return nil
+	}
+	return err
+}
+
+// walk walks the yaml struct data, where path is the concrete keys of data.
+func walk(data interface{}, path Path, fn WalkFunc, post bool) error {
+	if !post {
+		err := fn(path, data)
+		if err == SkipChildren {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, c := range children(data, path) {
+		if err := walk(c.Value, c.Path, fn, post); err != nil {
+			return err
+		}
+	}
+
+	if post {
+		if err := fn(path, data); err != nil && err != SkipChildren {
+			return err
+		}
+	}
+	return nil
+}
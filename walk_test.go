@@ -0,0 +1,99 @@
+package yamlconv
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	src := `{sriov: [{network: a, ip: 1}, {network: b}], gpu: {drivers: video}, port: 80}`
+	tests := []struct {
+		name string
+		fn   func(path Path) error
+		post bool
+		want []string
+	}{
+		{"pre-order",
+			func(Path) error { return nil }, false,
+			[]string{"", "sriov", "sriov[0]", "sriov[0].network", "sriov[0].ip",
+				"sriov[1]", "sriov[1].network", "gpu", "gpu.drivers", "port"}},
+		{"post-order",
+			func(Path) error { return nil }, true,
+			[]string{"sriov[0].network", "sriov[0].ip", "sriov[0]", "sriov[1].network",
+				"sriov[1]", "sriov", "gpu.drivers", "gpu", "port", ""}},
+		{"SkipChildren",
+			func(path Path) error {
+				if path.String() == "sriov" {
+					return SkipChildren
+				}
+				return nil
+			}, false,
+			[]string{"", "sriov", "gpu", "gpu.drivers", "port"}},
+		{"SkipChildren is ignored in post-order",
+			func(path Path) error {
+				if path.String() == "gpu" {
+					return SkipChildren
+				}
+				return nil
+			}, true,
+			[]string{"sriov[0].network", "sriov[0].ip", "sriov[0]", "sriov[1].network",
+				"sriov[1]", "sriov", "gpu.drivers", "gpu", "port", ""}},
+		{"Stop",
+			func(path Path) error {
+				if path.String() == "sriov[0].ip" {
+					return Stop
+				}
+				return nil
+			}, false,
+			[]string{"", "sriov", "sriov[0]", "sriov[0].network", "sriov[0].ip"}},
+		{"Stop in post-order",
+			func(path Path) error {
+				if path.String() == "sriov" {
+					return Stop
+				}
+				return nil
+			}, true,
+			[]string{"sriov[0].network", "sriov[0].ip", "sriov[0]", "sriov[1].network",
+				"sriov[1]", "sriov"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		fn := func(path Path, value interface{}) error {
+			got = append(got, path.String())
+			return tt.fn(path)
+		}
+		var err error
+		if tt.post {
+			err = WalkPostOrder(parseYaml(t, src), fn)
+		} else {
+			err = Walk(parseYaml(t, src), fn)
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWalkError(t *testing.T) {
+	errFound := errors.New("found")
+	var got []string
+	err := Walk(parseJson(t, `{"b": {"c": 1}, "a": [1, 2]}`), func(path Path, value interface{}) error {
+		got = append(got, path.String())
+		if value == 1 {
+			return errFound
+		}
+		return nil
+	})
+	if err != errFound {
+		t.Errorf("Walk: %v, want %v", err, errFound)
+	}
+	// the keys of golang maps are walked in the sorted order
+	want := []string{"", "a", "a[0]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk: %q, want %q", got, want)
+	}
+}
@@ -3,171 +3,97 @@
 package yamlconv
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strings"
 
 	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
 // Print prints the YAML-encoded string from the yaml struct data
-// to the standard out.
+// to the standard out, annotating the scalars with its type.
 // tab is used to spacing the nested yaml structures.
+// Use Printer for the other outputs and options.
+//
+// As ever, a newline is printed before each line instead of after it,
+// and a scalar data is printed after a space, e.g. " Str{10.10.0.101}",
+// so the caller prints the last newline.
 func Print(data interface{}, tab string) {
-	print(data, "", tab)
-}
+	var b bytes.Buffer
+	p := &Printer{Writer: &b, Indent: tab, Types: true}
+	p.Print(data)
+	text := strings.TrimSuffix(b.String(), "\n")
+	if len(text) == 0 {
+		return
+	}
 
-func print(data interface{}, tab, ntab string) {
-	switch m := data.(type) {
-	case []interface{}:
-		nArr := len(m)
-		for i, o := range m {
-			fmt.Printf("\n%sA[%d/%d]", tab, i, nArr)
-			print(o, ntab, ntab+ntab)
-		}
-	case map[string]interface{}:
-		for k, v := range m {
-			fmt.Printf("\n%sK[%s]", tab, k)
-			print(v, ntab, ntab+ntab)
-		}
-	case map[interface{}]interface{}:
-		for k, v := range m {
-			fmt.Printf("\n%sK[%s]", tab, k)
-			print(v, ntab, ntab+ntab)
-		}
-	case yaml.MapSlice:
-		for _, o := range m {
-			fmt.Printf("\n%sM[%s]", tab, o.Key)
-			print(o.Value, ntab, ntab+ntab)
-		}
-	case string:
-		fmt.Printf(" Str{%s}", m)
-	case bool:
-		if m {
-			fmt.Printf(" Bool{true}")
-		} else {
-			fmt.Printf(" Bool{false}")
-		}
-	case int:
-		fmt.Printf(" Int{%d}", m)
-	case nil:
-		fmt.Printf(" {}")
-	default:
-		fmt.Printf(" %T{%s}", m, m)
+	if node, ok := data.(*yamlv3.Node); ok {
+		data = nodeValue(node)
+	}
+	_, isMap := mapItems(data)
+	_, isList := data.([]interface{})
+	if isMap || isList {
+		fmt.Print("\n" + text)
+	} else {
+		fmt.Print(" " + text)
 	}
 }
 
-// MarshalJson returns the JSON encoding of the sub yaml struct data.
+// MarshalJson returns the JSON encoding of the sub yaml struct data,
+// encoded by Encoder without a newline.
 // keys are used to filter the match sub yaml struct.
-// a key in kyes must be a form of below:
-// - '[' Unsigned Integer ']', e.g. '[0]', '[10]', etc
-// - any string that can be used as golang map[] key.
-func MarshalJson(data interface{}, keys []string) ([]byte, error) {
+// a key in keys must be a form described in Path.
+func MarshalJson(data interface{}, keys Path) ([]byte, error) {
 	sub, err := Search(data, keys)
 	if err != nil {
 		return nil, err
 	}
 
-	return []byte(marshalJson(sub)), nil
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).encode(sub); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 // UnmarshalJson parses the yaml struct data and stores the result in
 // the value pointed to by v.
 // keys are used to filter the match sub yaml struct.
-// a key in kyes must be a form of below:
-// - '[' Unsigned Integer ']', e.g. '[0]', '[10]', etc
-// - any string that can be used as golang map[] key.
+// a key in keys must be a form described in Path.
+//
+// The sub yaml struct is converted as described in Encoder, so that
+// a []byte field accepts a !!binary of invalid UTF-8, and a time.Time
+// field accepts a timestamp of *yaml.Node of yaml.v3. A timestamp decoded
+// by yaml.v2 is a string, e.g. "2001-12-14", which a time.Time field does
+// not accept. The infinity and NaN are accepted only by string fields.
 //
 // If v is nil or not a pointer, it returns an InvalidUnmarshalError.
-func UnmarshalJson(data interface{}, keys []string, v any) error {
+func UnmarshalJson(data interface{}, keys Path, v any) error {
 	sub, err := Search(data, keys)
 	if err != nil {
 		return err
 	}
 
-	text := marshalJson(sub)
-	return json.Unmarshal([]byte(text), v)
-}
-
-// marshalJson returns the JSON-encoded string from the yaml struct data.
-func marshalJson(data interface{}) string {
-	switch m := data.(type) {
-	case []interface{}:
-		items := ""
-		sep := ""
-		for _, o := range m {
-			items = items + sep + marshalJson(o)
-			sep = ","
-		}
-		return "[" + items + "]"
-	case map[string]interface{}:
-		items := ""
-		sep := ""
-		for k, v := range m {
-			items = items + sep + marshalJson(k) + ":" + marshalJson(v)
-			sep = ","
-		}
-		return "{" + items + "}"
-	case map[interface{}]interface{}:
-		items := ""
-		sep := ""
-		for k, v := range m {
-			items = items + sep + marshalJson(k) + ":" + marshalJson(v)
-			sep = ","
-		}
-		return "{" + items + "}"
-	case yaml.MapSlice:
-		items := ""
-		sep := ""
-		for _, o := range m {
-			key := marshalJson(o.Key)
-			value := marshalJson(o.Value)
-			if len(key) > 0 {
-				if len(value) > 0 {
-					items = items + sep + key + ":" + value
-				} else {
-					items = items + sep + key
-				}
-			} else {
-				if len(value) > 0 {
-					items = items + sep + value
-				}
-			}
-			sep = ","
-		}
-		return "{" + items + "}"
-	case bool:
-		if m {
-			return "true"
-		} else {
-			return "false"
-		}
-	case int:
-		return fmt.Sprintf("%d", m)
-	case nil:
-		return "{}"
-	case string:
-		buf, _ := json.Marshal(m)
-		r0 := string(buf)
-		r1 := strings.ReplaceAll(r0, `\n`, "\\n")
-		r2 := strings.ReplaceAll(r1, `\r`, "\\r")
-		return r2
-	default:
-		return fmt.Sprintf("\"%s\"", m)
+	buf, err := MarshalJson(sub, Path{})
+	if err != nil {
+		return err
 	}
+	return json.Unmarshal(buf, v)
 }
 
 // Search returns the match sub-struct of yaml struct data.
 // keys are used to filter the match sub yaml struct.
-// a key in kyes must be a form of below:
-// - '[' Unsigned Integer ']', e.g. '[0]', '[10]', etc
-// - any string that can be used as golang map[] key.
+// a key in keys must be a form described in Path.
 //
 // It returns the same yaml struct, if the keys is empty.
+// If the keys match more than one sub yaml struct, e.g. with a wildcard,
+// it returns the first one found by SearchAll.
 //
 // An error is returned if there are no match keys or the length
 // of keys are longer than the one of nesting of yaml struct data.
-func Search(data interface{}, keys []string) (interface{}, error) {
+func Search(data interface{}, keys Path) (interface{}, error) {
 	if data == nil {
 		return nil, nil
 	}
@@ -176,42 +102,52 @@ func Search(data interface{}, keys []string) (interface{}, error) {
 		return data, nil
 	}
 
-	// index or pattern
-	idx := -1
-	search := ""
-	switch {
-	case keys[0][0] == '[':
-		n, err := fmt.Sscanf(keys[0][1:], "%d", &idx)
+	if !keys.IsConcrete() {
+		matches, err := SearchAll(data, keys)
 		if err != nil {
-			return data, &InvalidIndexError{
-				fmt.Errorf("invalid index: %s: %w", keys[0],
-					ErrInvalidIndexError)}
+			return nil, err
 		}
-		if n < 0 {
-			return data, &IndexOutOfRangeError{
-				fmt.Errorf("index out of range: %s: %w", keys[0],
-					ErrIndexOutOfRangeError)}
-		}
-	default:
-		search = keys[0]
+		return matches[0].Value, nil
 	}
 
+	sub, _, err := child(data, keys)
+	if err != nil {
+		return nil, err
+	}
+	return Search(sub, keys[1:])
+}
+
+// child returns the sub yaml struct of data which matches the first key
+// of keys, together with the key of its concrete Path.
+// The first key must not be a wildcard.
+func child(data interface{}, keys Path) (interface{}, string, error) {
+	// index or pattern
+	st, err := parseStep(keys[0])
+	if err != nil {
+		return nil, "", err
+	}
+	idx := st.index
+	search := st.name
+	isIndex := st.kind == indexStep
+
 	switch m := data.(type) {
 	case []interface{}:
-		if idx == -1 {
-			return nil, &InvalidIndexError{
+		if !isIndex {
+			return nil, "", &InvalidIndexError{
 				fmt.Errorf("expect key[%s], but []interface{}: %w", search,
 					ErrInvalidIndexError)}
 		}
-		if idx >= len(m) {
-			return nil, &NotFoundError{
-				fmt.Errorf("index %d out of len(arr) %d: %w", idx, len(m),
-					ErrNotFoundError)}
+		if st.end {
+			idx = len(m)
+		}
+		idx, err = listIndex(idx, len(m), "arr")
+		if err != nil {
+			return nil, "", err
 		}
-		return Search(m[idx], keys[1:])
+		return m[idx], fmt.Sprintf("[%d]", idx), nil
 	case map[string]interface{}:
-		if idx != -1 {
-			return nil, &InvalidIndexError{
+		if isIndex {
+			return nil, "", &InvalidIndexError{
 				fmt.Errorf("expect index %d, but map[]interface{}: %w", idx,
 					ErrInvalidIndexError)}
 		}
@@ -221,105 +157,150 @@ func Search(data interface{}, keys []string) (interface{}, error) {
 			for k := range m {
 				mkeys = append(mkeys, k)
 			}
-			return nil, &NotFoundError{
+			return nil, "", &NotFoundError{
 				fmt.Errorf("search %s not in %s: %w", search, mkeys,
 					ErrNotFoundError)}
 		}
-		return Search(i, keys[1:])
+		return i, mapKey(search), nil
 	case map[interface{}]interface{}:
-		if idx != -1 {
-			return nil, &InvalidIndexError{
+		if isIndex {
+			return nil, "", &InvalidIndexError{
 				fmt.Errorf("expect index %d, but map[]interface{}: %w", idx,
 					ErrInvalidIndexError)}
 		}
-		i, ok := m[search]
+		k, ok := lookup(m, search)
 		if !ok {
 			var mkeys []interface{}
 			for k := range m {
 				mkeys = append(mkeys, k)
 			}
-			return nil, &NotFoundError{
+			return nil, "", &NotFoundError{
 				fmt.Errorf("search %s not in %s: %w", search, mkeys,
 					ErrNotFoundError)}
 		}
-		return Search(i, keys[1:])
+		return m[k], mapKey(search), nil
 	case yaml.MapSlice:
-		if idx != -1 {
-			if idx >= len(m) {
-				return nil, &NotFoundError{
-					fmt.Errorf("index %d out of len(MapSlice) %d: %w", idx, len(m),
-						ErrNotFoundError)}
+		if isIndex {
+			if st.end {
+				idx = len(m)
 			}
-			return Search(m[idx].Value, keys[1:])
+			idx, err = listIndex(idx, len(m), "MapSlice")
+			if err != nil {
+				return nil, "", err
+			}
+			return m[idx].Value, fmt.Sprintf("[%d]", idx), nil
 		} else {
 			var mkeys []interface{}
 			for _, v := range m {
-				if search == v.Key {
-					return Search(v.Value, keys[1:])
+				if keyMatch(v.Key, search) {
+					return v.Value, mapKey(search), nil
 				}
 				mkeys = append(mkeys, v.Key)
 			}
-			return nil, &NotFoundError{
+			return nil, "", &NotFoundError{
 				fmt.Errorf("search %s not in %s: %w", search, mkeys,
 					ErrNotFoundError)}
 		}
+	case *yamlv3.Node:
+		sub, key, _, _, err := nodeChild(m, keys)
+		if err != nil {
+			return nil, "", err
+		}
+		return sub, key, nil
 	default:
-		if len(keys) > 0 {
-			return nil, &SearchKeyTooLongError{
-				fmt.Errorf("key left: %s: %w", keys,
-					ErrSearchKeyTooLongError)}
+		return nil, "", &SearchKeyTooLongError{
+			fmt.Errorf("key left: %s: %w", keys,
+				ErrSearchKeyTooLongError)}
+	}
+}
+
+// keyMatch reports whether the map key k matches the key name in Path.
+// A non-string key, e.g. 200 or true, matches its formatted value.
+func keyMatch(k interface{}, name string) bool {
+	if s, ok := k.(string); ok {
+		return s == name
+	}
+	return fmt.Sprint(k) == name
+}
+
+// keyName returns the key name in Path which matches the map key k.
+func keyName(k interface{}) string {
+	if s, ok := k.(string); ok {
+		return s
+	}
+	return fmt.Sprint(k)
+}
+
+// lookup returns the key of map m which matches the key name in Path.
+func lookup(m map[interface{}]interface{}, name string) (interface{}, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	for k := range m {
+		if keyMatch(k, name) {
+			return k, true
 		}
-		return data, nil
 	}
+	return nil, false
 }
 
 // Subtract returns the modified yaml struct data.
 // keys are used to filter out the matching sub yaml struct.
-// a key in kyes must be a form of below:
-// - '[' Unsigned Integer ']', e.g. '[0]', '[10]', etc
-// - any string that can be used as golang map[] key.
+// a key in keys must be a form described in Path.
 //
 // It returns the same yaml struct, if the keys is empty.
+// If the keys match more than one sub yaml struct, e.g. with a filter,
+// all the sub yaml structs found by SearchAll are filtered out.
 //
 // An error is returned if there are no match keys or the length
 // of keys are longer than the one of nesting of yaml struct data.
-func Subtract(data interface{}, keys []string) (interface{}, error) {
+func Subtract(data interface{}, keys Path) (interface{}, error) {
 	if len(keys) == 0 || len(keys[0]) == 0 {
 		// the end of search
 		return data, nil
 	}
 
-	// index or pattern
-	idx := -1
-	search := ""
-	switch {
-	case keys[0][0] == '[':
-		n, err := fmt.Sscanf(keys[0][1:], "%d", &idx)
+	if !keys.IsConcrete() {
+		matches, err := SearchAll(data, keys)
 		if err != nil {
-			return nil, &InvalidIndexError{
-				fmt.Errorf("invalid index: %s: %w", keys[0],
-					ErrInvalidIndexError)}
+			return nil, err
 		}
-		if n < 0 {
-			return nil, &IndexOutOfRangeError{
-				fmt.Errorf("index out of range: %s: %w", keys[0],
-					ErrIndexOutOfRangeError)}
+		for _, path := range subtractOrder(matches) {
+			data, err = Subtract(data, path)
+			if err != nil {
+				return nil, err
+			}
 		}
-	default:
-		search = keys[0]
+		return data, nil
 	}
 
+	// index or pattern
+	st, err := parseStep(keys[0])
+	if err != nil {
+		return nil, err
+	}
+	idx := st.index
+	search := st.name
+	isIndex := st.kind == indexStep
+
 	switch m := data.(type) {
+	case *yamlv3.Node:
+		if err := subtractNode(m, keys); err != nil {
+			return nil, err
+		}
+		return m, nil
 	case []interface{}:
-		if idx == -1 {
+		if !isIndex {
 			return nil, &InvalidIndexError{
 				fmt.Errorf("expect key[%s], but []interface{}: %w", search,
 					ErrInvalidIndexError)}
 		}
-		if idx >= len(m) {
-			return nil, &NotFoundError{
-				fmt.Errorf("index %d out of len(arr) %d: %w", idx, len(m),
-					ErrNotFoundError)}
+		if st.end {
+			idx = len(m)
+		}
+		idx, err = listIndex(idx, len(m), "arr")
+		if err != nil {
+			return nil, err
 		}
 		// the final
 		if len(keys) == 1 {
@@ -337,7 +318,7 @@ func Subtract(data interface{}, keys []string) (interface{}, error) {
 		}
 		return m, nil
 	case map[string]interface{}:
-		if idx != -1 {
+		if isIndex {
 			return nil, &InvalidIndexError{
 				fmt.Errorf("expect index %d, but map[]interface{}: %w", idx,
 					ErrInvalidIndexError)}
@@ -364,12 +345,12 @@ func Subtract(data interface{}, keys []string) (interface{}, error) {
 		}
 		return m, nil
 	case map[interface{}]interface{}:
-		if idx != -1 {
+		if isIndex {
 			return nil, &InvalidIndexError{
 				fmt.Errorf("expect index %d, but map[]interface{}: %w", idx,
 					ErrInvalidIndexError)}
 		}
-		i, ok := m[search]
+		k, ok := lookup(m, search)
 		if !ok {
 			var mkeys []interface{}
 			for k := range m {
@@ -381,21 +362,23 @@ func Subtract(data interface{}, keys []string) (interface{}, error) {
 		}
 		// the final
 		if len(keys) == 1 {
-			delete(m, search)
+			delete(m, k)
 		} else {
-			ret, err := Subtract(i, keys[1:])
+			ret, err := Subtract(m[k], keys[1:])
 			if err != nil {
 				return nil, err
 			}
-			m[search] = ret
+			m[k] = ret
 		}
 		return m, nil
 	case yaml.MapSlice:
-		if idx != -1 {
-			if idx >= len(m) {
-				return nil, &NotFoundError{
-					fmt.Errorf("index %d out of len(MapSlice) %d: %w", idx, len(m),
-						ErrNotFoundError)}
+		if isIndex {
+			if st.end {
+				idx = len(m)
+			}
+			idx, err = listIndex(idx, len(m), "MapSlice")
+			if err != nil {
+				return nil, err
 			}
 			// the final
 			if len(keys) == 1 {
@@ -415,7 +398,7 @@ func Subtract(data interface{}, keys []string) (interface{}, error) {
 		} else {
 			var mkeys []interface{}
 			for idx, v := range m {
-				if search == v.Key {
+				if keyMatch(v.Key, search) {
 					// the final
 					if len(keys) == 1 {
 						if idx == 0 {
@@ -447,3 +430,107 @@ func Subtract(data interface{}, keys []string) (interface{}, error) {
 		return data, nil
 	}
 }
+
+// mapItems returns the items of the map data in the order of
+// yaml.MapSlice, or sorted by its key name for golang maps.
+// It returns false, if data is not a map.
+func mapItems(data interface{}) (yaml.MapSlice, bool) {
+	switch m := data.(type) {
+	case map[string]interface{}:
+		items := make(yaml.MapSlice, 0, len(m))
+		for _, k := range sortedKeys(m) {
+			items = append(items, yaml.MapItem{Key: k, Value: m[k]})
+		}
+		return items, true
+	case map[interface{}]interface{}:
+		items := make(yaml.MapSlice, 0, len(m))
+		for _, k := range sortedKeys(m) {
+			items = append(items, yaml.MapItem{Key: k, Value: m[k]})
+		}
+		return items, true
+	case yaml.MapSlice:
+		return m, true
+	}
+	return nil, false
+}
+
+// mapGet returns the value of the map key k in the map data, where the
+// keys are compared by its key name.
+func mapGet(data interface{}, k interface{}) (interface{}, bool) {
+	v, _, err := child(data, Path{mapKey(keyName(k))})
+	return v, err == nil
+}
+
+// mapPut returns the map data, where the value of the map key k is v.
+// The new key is appended to yaml.MapSlice.
+func mapPut(data interface{}, k interface{}, v interface{}) interface{} {
+	switch m := data.(type) {
+	case map[string]interface{}:
+		m[keyName(k)] = v
+	case map[interface{}]interface{}:
+		if key, ok := lookup(m, keyName(k)); ok {
+			k = key
+		}
+		m[k] = v
+	case yaml.MapSlice:
+		for i, o := range m {
+			if keyMatch(o.Key, keyName(k)) {
+				m[i].Value = v
+				return m
+			}
+		}
+		return append(m, yaml.MapItem{Key: k, Value: v})
+	}
+	return data
+}
+
+// mapDelete returns the map data without the map key k.
+func mapDelete(data interface{}, k interface{}) interface{} {
+	switch m := data.(type) {
+	case map[string]interface{}:
+		delete(m, keyName(k))
+	case map[interface{}]interface{}:
+		if key, ok := lookup(m, keyName(k)); ok {
+			delete(m, key)
+		}
+	case yaml.MapSlice:
+		for i, o := range m {
+			if keyMatch(o.Key, keyName(k)) {
+				return append(m[:i:i], m[i+1:]...)
+			}
+		}
+	}
+	return data
+}
+
+// equal reports whether the yaml structs a and b are equal, where the
+// maps of any type are compared key by key, and the numbers are compared
+// by its value regardless of its type.
+func equal(a, b interface{}) bool {
+	if am, ok := mapItems(a); ok {
+		bm, ok := mapItems(b)
+		if !ok || len(am) != len(bm) {
+			return false
+		}
+		for _, o := range am {
+			v, ok := mapGet(b, o.Key)
+			if !ok || !equal(o.Value, v) {
+				return false
+			}
+		}
+		return true
+	}
+	if al, ok := a.([]interface{}); ok {
+		bl, ok := b.([]interface{})
+		if !ok || len(al) != len(bl) {
+			return false
+		}
+		for i := range al {
+			if !equal(al[i], bl[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return equalScalar(a, b)
+}
@@ -7,6 +7,11 @@ var (
 	ErrInvalidIndexError     = errors.New("invalid index")
 	ErrIndexOutOfRangeError  = errors.New("index out of range")
 	ErrSearchKeyTooLongError = errors.New("too many keys")
+	ErrInvalidPathError      = errors.New("invalid path")
+	ErrInvalidValueError     = errors.New("invalid value")
+	ErrInvalidPatchError     = errors.New("invalid patch")
+	ErrTestFailedError       = errors.New("test failed")
+	ErrInvalidEditError      = errors.New("invalid edit")
 )
 
 type NotFoundError struct {
@@ -48,3 +53,53 @@ func (e *SearchKeyTooLongError) Error() string {
 }
 
 func (e *SearchKeyTooLongError) Unwrap() error { return e.Err }
+
+type InvalidPathError struct {
+	Err error
+}
+
+func (e *InvalidPathError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InvalidPathError) Unwrap() error { return e.Err }
+
+type InvalidValueError struct {
+	Err error
+}
+
+func (e *InvalidValueError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InvalidValueError) Unwrap() error { return e.Err }
+
+type InvalidPatchError struct {
+	Err error
+}
+
+func (e *InvalidPatchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InvalidPatchError) Unwrap() error { return e.Err }
+
+type TestFailedError struct {
+	Err error
+}
+
+func (e *TestFailedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TestFailedError) Unwrap() error { return e.Err }
+
+type InvalidEditError struct {
+	Err error
+}
+
+func (e *InvalidEditError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InvalidEditError) Unwrap() error { return e.Err }